@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// options holds every setting of the command line tool.
+// They come from the defaults, then the --config file, then the command line flags.
+type options struct {
+	URL       string   `yaml:"url"`
+	URLs      []string `yaml:"urls"` // more seeds, crawled with the same settings
+	URLFile   string   `yaml:"url_file"`
+	Depth     int      `yaml:"depth"`
+	OutputDir string   `yaml:"output_dir"`
+	Workers   int      `yaml:"workers"`
+	Parallel  int      `yaml:"parallel"`
+	Combined  bool     `yaml:"combined"`
+
+	MaxPages    int           `yaml:"max_pages"`
+	MaxDuration time.Duration `yaml:"max_duration"`
+
+	Timeout            time.Duration     `yaml:"timeout"`
+	MaxAttempts        int               `yaml:"max_attempts"`
+	RetryBackoff       time.Duration     `yaml:"retry_backoff"`
+	RetryMaxBackoff    time.Duration     `yaml:"retry_max_backoff"`
+	Headers            map[string]string `yaml:"headers"`
+	HeaderHosts        []string          `yaml:"header_hosts"` // default: the hosts of the seed URLs
+	CookieFile         string            `yaml:"cookie_file"`
+	BasicAuth          *basicAuthOptions `yaml:"basic_auth"`
+	Login              *loginOptions     `yaml:"login"`
+	Proxy              string            `yaml:"proxy"`
+	ProxyList          string            `yaml:"proxy_list"`
+	IgnoreRobots       bool              `yaml:"ignore_robots"`
+	SkipNofollow       bool              `yaml:"skip_nofollow"`
+	CanonicalDedup     bool              `yaml:"canonical_dedup"`
+	Canonicalize       commaList         `yaml:"canonicalize"`
+	StripParams        []string          `yaml:"strip_params"`
+	Rate               float64           `yaml:"rate"`
+	GlobalRate         float64           `yaml:"global_rate"`
+	Jitter             float64           `yaml:"jitter"`
+	UseSitemap         bool              `yaml:"use_sitemap"`
+	Render             bool              `yaml:"render"`
+	ChromePath         string            `yaml:"chrome_path"`
+	CheckpointInterval time.Duration     `yaml:"checkpoint_interval"`
+	Progress           bool              `yaml:"progress"`
+	Events             string            `yaml:"events"`
+	EventsFile         string            `yaml:"events_file"`
+	MetricsAddr        string            `yaml:"metrics_addr"`
+
+	IncludePatterns []string `yaml:"include_patterns"`
+	ExcludePatterns []string `yaml:"exclude_patterns"`
+	PathPrefixes    []string `yaml:"path_prefixes"`
+	FilterRecorded  bool     `yaml:"filter_recorded"`
+
+	OutputFormats   commaList `yaml:"output_formats"`
+	Report          commaList `yaml:"report"`
+	CheckLinks      bool      `yaml:"check_links"`
+	ClassifyByType  bool      `yaml:"classify_by_content_type"`
+	Download        commaList `yaml:"download"`
+	DownloadWorkers int       `yaml:"download_workers"`
+	MaxFileSize     string    `yaml:"max_file_size"`
+	MaxTotalSize    string    `yaml:"max_total_size"`
+
+	Resume string `yaml:"-"`
+}
+
+// basicAuthOptions are the HTTP basic authentication credentials of the config file
+type basicAuthOptions struct {
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Hosts    []string `yaml:"hosts"` // default: the hosts of the seed URLs
+}
+
+// loginOptions describe the login form submitted before the crawl
+type loginOptions struct {
+	URL    string            `yaml:"url"`
+	Form   string            `yaml:"form"` // CSS selector of the form in the page at url
+	Fields map[string]string `yaml:"fields"`
+}
+
+func defaultOptions() options {
+	return options{
+		Depth:              1,
+		OutputDir:          "./scraping_results",
+		Workers:            1,
+		Parallel:           1,
+		Timeout:            15 * time.Second,
+		MaxAttempts:        1,
+		RetryBackoff:       time.Second,
+		RetryMaxBackoff:    30 * time.Second,
+		CheckpointInterval: 30 * time.Second,
+		OutputFormats:      commaList{"json"},
+		DownloadWorkers:    4,
+	}
+}
+
+// loadConfigFile overrides opts with the values defined in a YAML file
+func loadConfigFile(path string, opts *options) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+	defer file.Close()
+
+	// Unknown keys are reported instead of being silently ignored
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	err = decoder.Decode(opts)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// configPath finds the --config value before the flags are parsed, so that
+// the file values can become the flag defaults
+func configPath(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// commaList is a list flag given as comma-separated values (e.g. "json,csv")
+type commaList []string
+
+func (l *commaList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *commaList) Set(value string) error {
+	*l = commaList{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// headerFlag adds a "Name: value" header to the headers of the config file,
+// replacing the one with the same name
+type headerFlag struct {
+	headers *map[string]string
+}
+
+func (f headerFlag) String() string {
+	if f.headers == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(*f.headers))
+	for name, value := range *f.headers {
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (f headerFlag) Set(value string) error {
+	name, headerValue, found := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	if *f.headers == nil {
+		*f.headers = make(map[string]string)
+	}
+	(*f.headers)[name] = strings.TrimSpace(headerValue)
+	return nil
+}
+
+// repeatedList is a list flag given once per value (e.g. -exclude-pattern a -exclude-pattern b).
+// The first occurrence replaces the values coming from the config file.
+type repeatedList struct {
+	values *[]string
+	set    bool
+}
+
+func (l *repeatedList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ", ")
+}
+
+func (l *repeatedList) Set(value string) error {
+	if !l.set {
+		*l.values = nil
+		l.set = true
+	}
+	*l.values = append(*l.values, value)
+	return nil
+}
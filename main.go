@@ -1,705 +1,571 @@
 package main
 
 import (
-	"compress/flate"
-	"compress/gzip"
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"get-links/scraper"
 )
 
-// Ajout de structures pour la classification
-type LinkCategory string
-
-const (
-	CategoryHTML       LinkCategory = "html_pages"
-	CategoryDocument   LinkCategory = "documents"
-	CategoryImage      LinkCategory = "images"
-	CategoryScript     LinkCategory = "scripts"
-	CategoryStylesheet LinkCategory = "stylesheets"
-	CategoryMultimedia LinkCategory = "multimedia"
-	CategoryArchive    LinkCategory = "archives"
-	CategoryOther      LinkCategory = "other"
-)
-
-type ClassifiedLink struct {
-	URL      string       `json:"url"`
-	Category LinkCategory `json:"category"`
-	FileType string       `json:"file_type"`
-}
-
-type LinkScraper struct {
-	baseURL         *url.URL
-	client          *http.Client
-	visitedURL      map[string]bool
-	links           []string
-	internalLinks   []string
-	externalLinks   []string
-	classifiedLinks map[LinkCategory][]ClassifiedLink // Nouvelle structure pour la classification
-	errors          []string
-	mutex           sync.RWMutex
-	maxDepth        int
-	currentDepth    int
-	startTime       time.Time
-	outputDir       string
-}
-
-type ScrapingResults struct {
-	BaseURL          string                             `json:"base_url"`
-	TotalLinks       int                                `json:"total_links"`
-	InternalLinks    []string                           `json:"internal_links"`
-	ExternalLinks    []string                           `json:"external_links"`
-	AllLinks         []string                           `json:"all_links"`
-	ClassifiedLinks  map[LinkCategory][]ClassifiedLink `json:"classified_links"`
-	CategorySummary  map[LinkCategory]int               `json:"category_summary"`
-	Errors           []string                           `json:"errors"`
-	Statistics       ScrapingStats                      `json:"statistics"`
-	Timestamp        string                             `json:"timestamp"`
-}
-
-type ScrapingStats struct {
-	PagesVisited    int    `json:"pages_visited"`
-	TotalLinks      int    `json:"total_links"`
-	InternalCount   int    `json:"internal_count"`
-	ExternalCount   int    `json:"external_count"`
-	ErrorsCount     int    `json:"errors_count"`
-	ExecutionTime   string `json:"execution_time"`
-	MaxDepthReached int    `json:"max_depth_reached"`
-}
-
-// Définition des extensions par catégorie
-var fileExtensions = map[LinkCategory][]string{
-	CategoryHTML:       {".html", ".htm", ".xhtml", ".php", ".asp", ".aspx", ".jsp", ".do"},
-	CategoryDocument:   {".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".txt", ".rtf", ".csv"},
-	CategoryImage:      {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".ico", ".tiff", ".tif"},
-	CategoryScript:     {".js", ".mjs", ".ts"},
-	CategoryStylesheet: {".css", ".scss", ".sass", ".less"},
-	CategoryMultimedia: {".mp4", ".avi", ".mov", ".wmv", ".flv", ".webm", ".mp3", ".wav", ".ogg", ".m4a", ".flac"},
-	CategoryArchive:    {".zip", ".rar", ".7z", ".tar", ".gz", ".bz2", ".xz"},
-}
-
-func NewLinkScraper(baseURL string, maxDepth int, outputDir string) (*LinkScraper, error) {
-	parsedURL, err := url.Parse(baseURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %v", err)
+func main() {
+	// The config file values become the defaults of the flags, so that flags override them
+	opts := defaultOptions()
+	if path := configPath(os.Args[1:]); path != "" {
+		if err := loadConfigFile(path, &opts); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+	flag.String("config", "", "YAML configuration file (command line flags override its values)")
+	flag.StringVar(&opts.URL, "url", opts.URL, "URL of the website to scrape")
+	flag.StringVar(&opts.URLFile, "url-file", opts.URLFile, "File listing more websites to scrape, one URL per line")
+	flag.IntVar(&opts.Depth, "depth", opts.Depth, "Maximum depth for recursive scraping")
+	flag.StringVar(&opts.OutputDir, "output", opts.OutputDir, "Folder to save results")
+	flag.IntVar(&opts.Workers, "workers", opts.Workers, "Number of pages scraped concurrently")
+	flag.IntVar(&opts.Parallel, "parallel", opts.Parallel, "Number of websites scraped at the same time, when several are given")
+	flag.BoolVar(&opts.Combined, "combined", opts.Combined, "Save a single report for all the websites, with figures per domain")
+	flag.IntVar(&opts.MaxPages, "max-pages", opts.MaxPages, "Stop the crawl after scraping this many pages (0 = unlimited)")
+	flag.DurationVar(&opts.MaxDuration, "max-duration", opts.MaxDuration, "Stop the run after this duration, e.g. 10m (0 = unlimited)")
+	flag.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "Timeout of each HTTP request")
+	flag.IntVar(&opts.MaxAttempts, "max-attempts", opts.MaxAttempts, "Attempts per request on timeouts, 429, 503 and other transient errors (1 = no retry)")
+	flag.DurationVar(&opts.RetryBackoff, "retry-backoff", opts.RetryBackoff, "Delay before the first retry, doubled after each attempt")
+	flag.DurationVar(&opts.RetryMaxBackoff, "retry-max-backoff", opts.RetryMaxBackoff, "Longest delay between two attempts (a Retry-After header takes precedence)")
+	flag.Var(headerFlag{headers: &opts.Headers}, "header", "Add this \"Name: value\" header to the requests to the website, e.g. \"Authorization: Bearer ...\" (repeatable)")
+	flag.Var(&repeatedList{values: &opts.HeaderHosts}, "header-host", "Send the -header values to this host instead of the website's (repeatable)")
+	flag.StringVar(&opts.CookieFile, "cookie-file", opts.CookieFile, "Load the cookies of this file (Netscape format, as exported by curl or a browser)")
+	flag.StringVar(&opts.Proxy, "proxy", opts.Proxy, "Send the requests through this proxy (http://, https:// or socks5://)")
+	flag.StringVar(&opts.ProxyList, "proxy-list", opts.ProxyList, "File listing proxies, one per line, used in turn")
+	flag.BoolVar(&opts.IgnoreRobots, "ignore-robots", opts.IgnoreRobots, "Ignore robots.txt rules and Crawl-delay")
+	flag.BoolVar(&opts.SkipNofollow, "skip-nofollow", opts.SkipNofollow, "Don't follow rel=nofollow links")
+	flag.BoolVar(&opts.CanonicalDedup, "canonical-dedup", opts.CanonicalDedup, "Skip the pages whose <link rel=canonical> URL was already scraped")
+	flag.Var(&opts.Canonicalize, "canonicalize", "More rules telling which URLs are the same link, comma-separated: ignore-scheme, index-file, trailing-slash, sort-query")
+	flag.Var(&repeatedList{values: &opts.StripParams}, "strip-param", "Ignore this query parameter when comparing URLs, e.g. sessionid or utm_* (repeatable)")
+	flag.Float64Var(&opts.Rate, "rate", opts.Rate, "Maximum requests per second to a single host (0 = unlimited)")
+	flag.Float64Var(&opts.GlobalRate, "global-rate", opts.GlobalRate, "Maximum requests per second over all hosts (0 = unlimited)")
+	flag.Float64Var(&opts.Jitter, "jitter", opts.Jitter, "Random extra delay between requests, as a fraction of the per-host interval (e.g. 0.5)")
+	flag.StringVar(&opts.Resume, "resume", opts.Resume, "Resume the crawl saved in this state file (the URL becomes optional)")
+	flag.BoolVar(&opts.UseSitemap, "use-sitemap", opts.UseSitemap, "Seed the crawl with the URLs listed in the site's XML sitemaps")
+	flag.BoolVar(&opts.Render, "render", opts.Render, "Load pages in headless Chrome to find the links added by JavaScript")
+	flag.StringVar(&opts.ChromePath, "chrome-path", opts.ChromePath, "Chrome or Chromium binary used by -render (default: looked up automatically)")
+	flag.BoolVar(&opts.CheckLinks, "check-links", opts.CheckLinks, "Check the HTTP status of every discovered link and report broken ones")
+	flag.BoolVar(&opts.ClassifyByType, "classify-by-content-type", opts.ClassifyByType, "Request the links without a telling extension and classify them by Content-Type")
+	flag.Var(&opts.Download, "download", "Download the links of these categories, comma-separated (e.g. documents,images)")
+	flag.IntVar(&opts.DownloadWorkers, "download-workers", opts.DownloadWorkers, "Number of concurrent downloads")
+	flag.StringVar(&opts.MaxFileSize, "max-file-size", opts.MaxFileSize, "Skip downloads larger than this size (e.g. 10MB)")
+	flag.StringVar(&opts.MaxTotalSize, "max-total-size", opts.MaxTotalSize, "Stop downloading once this total size is reached (e.g. 1GB)")
+	flag.Var(&opts.OutputFormats, "output-format", "Output formats, comma-separated: json, csv, ndjson, txt")
+	flag.Var(&opts.Report, "report", "Human readable reports, comma-separated: html, md")
+	flag.DurationVar(&opts.CheckpointInterval, "checkpoint-interval", opts.CheckpointInterval, "How often the crawl state is saved")
+	flag.BoolVar(&opts.Progress, "progress", opts.Progress, "Show a status line with the crawl progress, refreshed in place")
+	flag.StringVar(&opts.Events, "events", opts.Events, "Stream the crawl events in this format: ndjson (to the standard output unless -events-file is set)")
+	flag.StringVar(&opts.MetricsAddr, "metrics-addr", opts.MetricsAddr, "Serve Prometheus metrics on /metrics, and /healthz and /status, at this address (e.g. :9090)")
+	flag.StringVar(&opts.EventsFile, "events-file", opts.EventsFile, "Write the -events stream to this file")
+	flag.Var(&repeatedList{values: &opts.IncludePatterns}, "include-pattern", "Only follow URLs matching this regular expression (repeatable)")
+	flag.Var(&repeatedList{values: &opts.ExcludePatterns}, "exclude-pattern", "Don't follow URLs matching this regular expression (repeatable)")
+	flag.Var(&repeatedList{values: &opts.PathPrefixes}, "path-prefix", "Only follow URLs whose path starts with this prefix, e.g. /docs/ (repeatable)")
+	flag.BoolVar(&opts.FilterRecorded, "filter-recorded", opts.FilterRecorded, "Apply the URL filters to the recorded links too, not only to the followed ones")
+
+	flag.Usage = func() {
+		fmt.Println("Usage: go run get-links [options] <URL>... [max_depth] [output_folder]")
+		fmt.Println("Example: go run get-links -workers 8 https://example.com 2 ./results")
+		fmt.Println("Batch: go run get-links -parallel 2 -combined https://example.com https://example.org 2")
+		fmt.Println("Config: go run get-links -config scrape.yaml -depth 3")
+		fmt.Println("Resume: go run get-links -resume ./results/state.json")
+		fmt.Println("Parameters (same as -url, -depth and -output):")
+		fmt.Println("  URL: The URL of the website to scrape, several URLs can be given")
+		fmt.Println("  max_depth: Maximum depth for recursive scraping (default: 1)")
+		fmt.Println("  output_folder: Folder to save results (default: ./scraping_results)")
+		fmt.Println("Options:")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	client := &http.Client{
-		Transport: tr,
-		Timeout:   15 * time.Second,
+	// Positional parameters are kept for compatibility and win over the flags
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	// The first parameter is a URL, and so are the next ones containing "://"
+	args := flag.Args()
+	if len(args) > 0 {
+		opts.URL = args[0]
+		opts.URLs = nil
+		explicit["url"] = true
+		args = args[1:]
+		for len(args) > 0 && strings.Contains(args[0], "://") {
+			opts.URLs = append(opts.URLs, args[0])
+			args = args[1:]
+		}
 	}
-
-	if outputDir != "" {
-		err := os.MkdirAll(outputDir, 0755)
+	if len(args) > 0 {
+		depth, err := strconv.Atoi(args[0])
 		if err != nil {
-			return nil, fmt.Errorf("failed to create output directory: %v", err)
+			log.Fatalf("❌ Invalid max_depth: %q", args[0])
 		}
+		opts.Depth = depth
+		explicit["depth"] = true
 	}
-
-	// Initialisation de la map pour les liens classifiés
-	classifiedLinks := make(map[LinkCategory][]ClassifiedLink)
-	for category := range fileExtensions {
-		classifiedLinks[category] = make([]ClassifiedLink, 0)
-	}
-	classifiedLinks[CategoryOther] = make([]ClassifiedLink, 0)
-
-	return &LinkScraper{
-		baseURL:         parsedURL,
-		client:          client,
-		visitedURL:      make(map[string]bool),
-		links:           make([]string, 0),
-		internalLinks:   make([]string, 0),
-		externalLinks:   make([]string, 0),
-		classifiedLinks: classifiedLinks,
-		errors:          make([]string, 0),
-		maxDepth:        maxDepth,
-		currentDepth:    0,
-		startTime:       time.Now(),
-		outputDir:       outputDir,
-	}, nil
-}
-
-// Nouvelle fonction pour classifier un lien
-func (ls *LinkScraper) classifyLink(link string) (LinkCategory, string) {
-	parsedURL, err := url.Parse(link)
-	if err != nil {
-		return CategoryOther, "unknown"
-	}
-
-	path := strings.ToLower(parsedURL.Path)
-	
-	// Si pas d'extension, vérifier si c'est probablement une page HTML
-	if !strings.Contains(path, ".") || strings.HasSuffix(path, "/") {
-		return CategoryHTML, "html"
+	if len(args) > 1 {
+		opts.OutputDir = args[1]
+		explicit["output"] = true
 	}
 
-	// Extraire l'extension
-	ext := filepath.Ext(path)
-	if ext == "" {
-		return CategoryHTML, "html"
-	}
+	var state *scraper.State
+	if opts.Resume != "" {
+		var err error
+		state, err = scraper.LoadState(opts.Resume)
+		if err != nil {
+			log.Fatalf("❌ Error loading state: %v", err)
+		}
 
-	// Chercher dans nos catégories
-	for category, extensions := range fileExtensions {
-		for _, fileExt := range extensions {
-			if ext == fileExt {
-				return category, strings.TrimPrefix(ext, ".")
-			}
+		// A resumed crawl keeps its URL, depth and output directory unless given again
+		// and only continues its own website
+		if !explicit["url"] {
+			opts.URL = state.BaseURL
+			opts.URLs = nil
+		}
+		if !explicit["url-file"] {
+			opts.URLFile = ""
+		}
+		if !explicit["depth"] {
+			opts.Depth = state.MaxDepth
+		}
+		if !explicit["output"] {
+			opts.OutputDir = filepath.Dir(opts.Resume)
 		}
 	}
 
-	return CategoryOther, strings.TrimPrefix(ext, ".")
-}
-
-func (ls *LinkScraper) addLink(link string) {
-	ls.mutex.Lock()
-	defer ls.mutex.Unlock()
+	seeds, err := seedList(opts)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if len(seeds) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
 
-	// Éviter les doublons
-	for _, existingLink := range ls.links {
-		if existingLink == link {
-			return
+	var downloadOpts *scraper.DownloadOptions
+	if len(opts.Download) > 0 {
+		downloadOptions, err := parseDownloadOptions(opts.Download, opts.MaxFileSize, opts.MaxTotalSize)
+		if err != nil {
+			log.Fatalf("❌ Invalid download options: %v", err)
 		}
+		downloadOptions.Workers = opts.DownloadWorkers
+		downloadOpts = downloadOptions
 	}
 
-	ls.links = append(ls.links, link)
-
-	// Classifier le lien
-	category, fileType := ls.classifyLink(link)
-	classifiedLink := ClassifiedLink{
-		URL:      link,
-		Category: category,
-		FileType: fileType,
+	console := consoleOutput(opts)
+	reporter, progress, closeEvents, err := newReporter(opts, console)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-	ls.classifiedLinks[category] = append(ls.classifiedLinks[category], classifiedLink)
+	defer closeEvents()
 
-	// Catégoriser comme interne ou externe
-	if ls.isInternalLink(link) {
-		ls.internalLinks = append(ls.internalLinks, link)
+	if len(seeds) == 1 {
+		fmt.Fprintf(console, "🚀 Starting ultra-fast scraping of: %s\n", seeds[0])
 	} else {
-		ls.externalLinks = append(ls.externalLinks, link)
+		fmt.Fprintf(console, "🚀 Starting ultra-fast scraping of %d websites: %s\n", len(seeds), strings.Join(seeds, ", "))
 	}
-}
-
-func (ls *LinkScraper) GetResults() ScrapingResults {
-	ls.mutex.RLock()
-	defer ls.mutex.RUnlock()
-
-	// Créer un résumé par catégorie
-	categorySummary := make(map[LinkCategory]int)
-	for category, links := range ls.classifiedLinks {
-		categorySummary[category] = len(links)
-	}
-
-	return ScrapingResults{
-		BaseURL:         ls.baseURL.String(),
-		TotalLinks:      len(ls.links),
-		InternalLinks:   ls.internalLinks,
-		ExternalLinks:   ls.externalLinks,
-		AllLinks:        ls.links,
-		ClassifiedLinks: ls.classifiedLinks,
-		CategorySummary: categorySummary,
-		Errors:          ls.errors,
-		Statistics: ScrapingStats{
-			PagesVisited:    len(ls.visitedURL),
-			TotalLinks:      len(ls.links),
-			InternalCount:   len(ls.internalLinks),
-			ExternalCount:   len(ls.externalLinks),
-			ErrorsCount:     len(ls.errors),
-			ExecutionTime:   time.Since(ls.startTime).String(),
-			MaxDepthReached: ls.currentDepth,
-		},
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	fmt.Fprintf(console, "📊 Maximum depth: %d\n", opts.Depth)
+	fmt.Fprintf(console, "👷 Workers: %d\n", opts.Workers)
+	if len(seeds) > 1 {
+		fmt.Fprintf(console, "🌱 Websites in parallel: %d\n", opts.Parallel)
 	}
-}
+	fmt.Fprintf(console, "💾 Output directory: %s\n", opts.OutputDir)
+	fmt.Fprintln(console, strings.Repeat("-", 50))
 
-func (ls *LinkScraper) PrintDetailedStats() {
-	results := ls.GetResults()
-
-	fmt.Printf("\n" + strings.Repeat("=", 50) + "\n")
-	fmt.Printf("📊 DETAILED STATISTICS\n")
-	fmt.Printf(strings.Repeat("=", 50) + "\n")
-	fmt.Printf("🌐 Website: %s\n", results.BaseURL)
-	fmt.Printf("⏱️  Execution Time: %s\n", results.Statistics.ExecutionTime)
-	fmt.Printf("📄 Pages Visited: %d\n", results.Statistics.PagesVisited)
-	fmt.Printf("🔗 Total Links: %d\n", results.Statistics.TotalLinks)
-	fmt.Printf("🏠 Internal Links: %d\n", results.Statistics.InternalCount)
-	fmt.Printf("🌍 External Links: %d\n", results.Statistics.ExternalCount)
-	fmt.Printf("📊 Max Depth Reached: %d\n", results.Statistics.MaxDepthReached)
-	fmt.Printf("❌ Errors Encountered: %d\n", results.Statistics.ErrorsCount)
-
-	// Afficher le résumé par catégorie
-	fmt.Printf("\n📂 LINKS BY CATEGORY:\n")
-	categoryIcons := map[LinkCategory]string{
-		CategoryHTML:       "📄",
-		CategoryDocument:   "📑",
-		CategoryImage:      "🖼️",
-		CategoryScript:     "⚙️",
-		CategoryStylesheet: "🎨",
-		CategoryMultimedia: "🎬",
-		CategoryArchive:    "📦",
-		CategoryOther:      "❓",
-	}
-
-	for category, count := range results.CategorySummary {
-		if count > 0 {
-			icon := categoryIcons[category]
-			fmt.Printf("   %s %s: %d\n", icon, strings.Title(string(category)), count)
-		}
+	proxies, err := proxyList(opts.Proxy, opts.ProxyList)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-
-	// Afficher quelques exemples par catégorie
-	fmt.Printf("\n📋 SAMPLE LINKS BY CATEGORY:\n")
-	for category, links := range results.ClassifiedLinks {
-		if len(links) > 0 {
-			icon := categoryIcons[category]
-			fmt.Printf("\n%s %s (%d total):\n", icon, strings.Title(string(category)), len(links))
-			// Afficher max 3 exemples par catégorie
-			maxExamples := 3
-			if len(links) < maxExamples {
-				maxExamples = len(links)
-			}
-			for i := 0; i < maxExamples; i++ {
-				fmt.Printf("   • [%s] %s\n", links[i].FileType, links[i].URL)
-			}
-			if len(links) > 3 {
-				fmt.Printf("   ... and %d more\n", len(links)-3)
-			}
-		}
+	if len(proxies) > 0 {
+		fmt.Fprintf(console, "🌐 Proxies: %d\n", len(proxies))
 	}
 
-	if len(results.Errors) > 0 {
-		fmt.Printf("\n🚨 ERRORS:\n")
-		for _, err := range results.Errors {
-			fmt.Printf("   • %s\n", err)
+	// Pages are loaded by headless Chrome with -render, by plain HTTP requests otherwise
+	var fetcher scraper.Fetcher
+	if opts.Render {
+		// The browser has its own cookies: those of the crawl never reach it
+		if opts.CookieFile != "" || opts.BasicAuth != nil || opts.Login != nil {
+			log.Fatalf("❌ -render can't be used with -cookie-file, basic_auth or login")
+		}
+		fmt.Fprintf(console, "🖥️  Starting headless browser...\n")
+		// The browser can't rotate proxies, it only uses -proxy
+		if opts.ProxyList != "" {
+			fmt.Fprintf(console, "⚠️  -proxy-list is not used by the headless browser, only by the other requests\n")
+		}
+		if len(opts.Headers) > 0 {
+			fmt.Fprintf(console, "⚠️  The headless browser sends -header to every host it loads from, not only to -header-host\n")
+		}
+		renderFetcher, err := scraper.NewRenderFetcher(scraper.RenderOptions{
+			ExecPath: opts.ChromePath,
+			Headers:  opts.Headers,
+			Proxy:    opts.Proxy,
+			Timeout:  opts.Timeout,
+		})
+		if err != nil {
+			log.Fatalf("❌ %v", err)
 		}
+		defer renderFetcher.Close()
+		fetcher = renderFetcher
 	}
 
-	fmt.Printf(strings.Repeat("=", 50) + "\n")
-}
-
-// Ajouter une fonction pour sauvegarder les résultats classifiés dans des fichiers séparés
-func (ls *LinkScraper) SaveClassifiedResults() error {
-	if ls.outputDir == "" {
-		return nil
+	var cookies []*http.Cookie
+	if opts.CookieFile != "" {
+		cookies, err = scraper.LoadCookieFile(opts.CookieFile)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Fprintf(console, "🍪 Cookies: %d loaded from %s\n", len(cookies), opts.CookieFile)
 	}
 
-	results := ls.GetResults()
-	domain := strings.ReplaceAll(ls.baseURL.Host, ".", "_")
-	timestamp := time.Now().Format("20060102_150405")
-
-	// Créer un sous-dossier pour cette session
-	sessionDir := filepath.Join(ls.outputDir, fmt.Sprintf("%s_%s", domain, timestamp))
-	err := os.MkdirAll(sessionDir, 0755)
-	if err != nil {
-		return fmt.Errorf("error creating session directory: %v", err)
+	var basicAuth *scraper.BasicAuth
+	if opts.BasicAuth != nil {
+		basicAuth = &scraper.BasicAuth{
+			Username: opts.BasicAuth.Username,
+			Password: opts.BasicAuth.Password,
+			Hosts:    opts.BasicAuth.Hosts,
+		}
 	}
 
-	// Sauvegarder le résumé principal
-	mainFile := filepath.Join(sessionDir, "summary.json")
-	jsonData, err := json.MarshalIndent(results, "", "  ")
+	canonicalRules, err := scraper.ParseCanonicalRules(opts.Canonicalize)
 	if err != nil {
-		return fmt.Errorf("error encoding JSON: %v", err)
-	}
-	err = os.WriteFile(mainFile, jsonData, 0644)
+		log.Fatalf("❌ %v", err)
+	}
+	if len(opts.StripParams) > 0 {
+		canonicalRules = append(canonicalRules, scraper.StripQueryParams(opts.StripParams...))
+	}
+
+	var metrics *scraper.Metrics
+	if opts.MetricsAddr != "" {
+		metrics = scraper.NewMetrics()
+	}
+
+	retry := scraper.RetryPolicy{
+		MaxAttempts:    opts.MaxAttempts,
+		InitialBackoff: opts.RetryBackoff,
+		MaxBackoff:     opts.RetryMaxBackoff,
+	}
+
+	// Create the scrapers, one per website
+	batch, err := scraper.NewBatch(scraper.Config{
+		MaxDepth:           opts.Depth,
+		MaxPages:           opts.MaxPages,
+		OutputDir:          opts.OutputDir,
+		OutputFormats:      outputFormats(opts.OutputFormats),
+		Reports:            reportFormats(opts.Report),
+		Workers:            opts.Workers,
+		Timeout:            opts.Timeout,
+		Headers:            opts.Headers,
+		HeaderHosts:        opts.HeaderHosts,
+		Cookies:            cookies,
+		BasicAuth:          basicAuth,
+		Reporter:           reporter,
+		Metrics:            metrics,
+		Retry:              retry,
+		Fetcher:            fetcher,
+		Proxies:            proxies,
+		IgnoreRobots:       opts.IgnoreRobots,
+		SkipNofollow:       opts.SkipNofollow,
+		CanonicalDedup:     opts.CanonicalDedup,
+		CanonicalRules:     canonicalRules,
+		UseSitemap:         opts.UseSitemap,
+		RateLimit:          opts.Rate,
+		GlobalRateLimit:    opts.GlobalRate,
+		RateJitter:         opts.Jitter,
+		StateFile:          opts.Resume,
+		CheckpointInterval: opts.CheckpointInterval,
+		IncludePatterns:    opts.IncludePatterns,
+		ExcludePatterns:    opts.ExcludePatterns,
+		PathPrefixes:       opts.PathPrefixes,
+		FilterRecorded:     opts.FilterRecorded,
+		Resume:             state,
+	}, seeds, scraper.BatchOptions{Parallel: opts.Parallel, Combined: opts.Combined})
 	if err != nil {
-		return fmt.Errorf("error writing summary file: %v", err)
+		log.Fatalf("❌ Error creating scraper: %v", err)
 	}
 
-	// Sauvegarder chaque catégorie dans un fichier séparé
-	for category, links := range results.ClassifiedLinks {
-		if len(links) > 0 {
-			categoryFile := filepath.Join(sessionDir, fmt.Sprintf("%s.json", category))
-			categoryData, err := json.MarshalIndent(links, "", "  ")
-			if err != nil {
-				continue
-			}
-			os.WriteFile(categoryFile, categoryData, 0644)
+	// Serve the metrics and the status of the crawl until the program exits
+	if opts.MetricsAddr != "" {
+		listener, err := net.Listen("tcp", opts.MetricsAddr)
+		if err != nil {
+			log.Fatalf("❌ Error starting metrics server: %v", err)
 		}
+		go http.Serve(listener, batch.MonitorHandler())
+		fmt.Fprintf(console, "📈 Metrics: http://%s/metrics (status: /status, health: /healthz)\n", listener.Addr())
 	}
 
-	fmt.Printf("💾 Results saved to: %s\n", sessionDir)
-	return nil
-}
-
-// Les autres fonctions restent identiques (addError, ScrapeLinksRecursive, scrapePage, etc.)
-// Je n'ai modifié que les parties concernant la classification
-
-func (ls *LinkScraper) addError(err string) {
-	ls.mutex.Lock()
-	defer ls.mutex.Unlock()
-	ls.errors = append(ls.errors, fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), err))
-	fmt.Printf("❌ ERROR: %s\n", err)
-}
-
-func (ls *LinkScraper) ScrapeLinksRecursive(targetURL string, depth int) {
-	if depth > ls.maxDepth {
-		return
+	if state != nil {
+		fmt.Fprintf(console, "♻️  Resuming crawl saved at %s (%d pages left)\n", state.SavedAt, len(state.Frontier))
 	}
 
-	ls.mutex.RLock()
-	visited := ls.visitedURL[targetURL]
-	ls.mutex.RUnlock()
+	// Stop gracefully on Ctrl+C or SIGTERM: cancel the requests in progress,
+	// then save the state and the partial results. A second signal exits immediately.
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signalCtx.Done()
+		stop()
+	}()
 
-	if visited {
-		return
+	ctx := signalCtx
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(signalCtx, opts.MaxDuration)
+		defer cancel()
 	}
 
-	ls.mutex.Lock()
-	ls.visitedURL[targetURL] = true
-	if depth > ls.currentDepth {
-		ls.currentDepth = depth
-	}
-	ls.mutex.Unlock()
-
-	fmt.Printf("🔍 [Depth %d] Scraping: %s\n", depth, targetURL)
-
-	newInternalLinks, err := ls.scrapePage(targetURL, depth)
-	if err != nil {
-		ls.addError(fmt.Sprintf("Error on %s: %v", targetURL, err))
-		return
+	// Log in before the crawl, the session cookies are kept for the whole run
+	if opts.Login != nil {
+		err := batch.Login(ctx, scraper.LoginStep{
+			URL:    opts.Login.URL,
+			Form:   opts.Login.Form,
+			Fields: opts.Login.Fields,
+		})
+		if err != nil {
+			log.Fatalf("❌ Login failed: %v", err)
+		}
 	}
 
-	if depth < ls.maxDepth {
-		for _, link := range newInternalLinks {
-			ls.mutex.RLock()
-			alreadyVisited := ls.visitedURL[link]
-			ls.mutex.RUnlock()
-
-			if !alreadyVisited {
-				ls.ScrapeLinksRecursive(link, depth+1)
-			}
+	// Initial connection test
+	for i, seed := range seeds {
+		fmt.Fprintf(console, "🔗 Testing connection to %s...\n", seed)
+		status, err := batch.Scrapers()[i].TestConnection(ctx)
+		if err == nil {
+			fmt.Fprintf(console, "✅ Connection successful (Status: %d)\n", status)
+		} else {
+			fmt.Fprintf(console, "⚠️  Connection test failed, but continuing: %v\n", err)
 		}
 	}
-}
 
-func (ls *LinkScraper) scrapePage(targetURL string, depth int) ([]string, error) {
-	// Create request with realistic headers
-	req, err := http.NewRequest("GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
-	}
-
-	// Realistic headers to avoid blocking
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9,fr;q=0.8")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Cache-Control", "max-age=0")
-
-	// Make HTTP request
-	resp, err := ls.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
+	// Start crawling
+	if progress != nil {
+		progress.Start(batch.Progress, 500*time.Millisecond)
+	}
+	err = batch.Scrape(ctx)
+	if progress != nil {
+		progress.Stop()
+	}
+	switch {
+	case signalCtx.Err() != nil:
+		fmt.Fprintf(console, "\n🛑 Interrupted, saving partial results...\n")
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Fprintf(console, "⏱️  Maximum duration reached (%s), saving partial results...\n", opts.MaxDuration)
 	}
-	defer resp.Body.Close()
 
-	var reader io.Reader
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		gzReader, err := gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("error creating gzip reader: %v", err)
-		}
-		defer gzReader.Close()
-		reader = gzReader
-	case "deflate":
-		flReader := flate.NewReader(resp.Body)
-		defer flReader.Close()
-		reader = flReader
-	default:
-		reader = resp.Body
+	if opts.ClassifyByType && ctx.Err() == nil {
+		batch.ClassifyByContentType(ctx)
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	if opts.CheckLinks && ctx.Err() == nil {
+		batch.CheckLinks(ctx)
 	}
 
-	// Check Content-Type
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(strings.ToLower(contentType), "text/html") {
-		return nil, fmt.Errorf("non-HTML content detected: %s", contentType)
+	if downloadOpts != nil && ctx.Err() == nil {
+		if err := batch.DownloadAssets(ctx, *downloadOpts); err != nil {
+			fmt.Fprintf(console, "⚠️  Error downloading assets: %v\n", err)
+		}
 	}
 
-	// Parse HTML
-	doc, err := goquery.NewDocumentFromReader(reader)
+	// Save results
+	err = batch.SaveResults()
 	if err != nil {
-		return nil, fmt.Errorf("error parsing HTML: %v", err)
+		fmt.Fprintf(console, "⚠️  Error saving results: %v\n", err)
 	}
 
-	fmt.Printf("✅ Page loaded successfully: %s\n", targetURL)
-
-	// Extract all links
-	linkCount := 0
-	newInternalLinks := []string{}
-
-	// Extract <a href=""> links
-	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
+	// Print detailed statistics
+	batch.PrintDetailedStats(console)
 
-		// Clean and normalize URL
-		cleanURL := ls.normalizeURL(href, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
-			
-			// Only add HTML pages to internal links for recursive scraping
-			category, _ := ls.classifyLink(cleanURL)
-			if ls.isInternalLink(cleanURL) && category == CategoryHTML {
-				newInternalLinks = append(newInternalLinks, cleanURL)
-			}
+	if ctx.Err() != nil {
+		fmt.Fprintf(console, "\n⚠️  Scraping stopped before the end, partial results saved. Continue with:\n")
+		for _, ls := range batch.Scrapers() {
+			fmt.Fprintf(console, "   -resume %s\n", ls.StateFile())
 		}
-	})
+		return
+	}
+	fmt.Fprintf(console, "\n✅ Scraping completed successfully!\n")
+}
 
-	// Extract <link> elements
-	doc.Find("link[href]").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
+// consoleOutput returns where the messages meant for humans are written: the
+// standard output, or the standard error when it carries the -events stream.
+func consoleOutput(opts options) io.Writer {
+	if opts.Events != "" && opts.EventsFile == "" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
 
-		rel, _ := s.Attr("rel")
-		// Only keep certain types of links
-		if strings.Contains(rel, "canonical") || strings.Contains(rel, "alternate") {
-			cleanURL := ls.normalizeURL(href, targetURL)
-			if cleanURL != "" {
-				ls.addLink(cleanURL)
-				linkCount++
-				
-				category, _ := ls.classifyLink(cleanURL)
-				if ls.isInternalLink(cleanURL) && category == CategoryHTML {
-					newInternalLinks = append(newInternalLinks, cleanURL)
-				}
+// newReporter builds the reporter of the run from -progress, -events and -events-file.
+// The console and progress reporters print to console.
+func newReporter(opts options, console io.Writer) (scraper.Reporter, *scraper.ProgressReporter, func(), error) {
+	closeEvents := func() {}
+	var events scraper.Reporter
+	switch opts.Events {
+	case "":
+	case "ndjson":
+		out := os.Stdout
+		if opts.EventsFile != "" {
+			file, err := os.Create(opts.EventsFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error creating events file: %v", err)
 			}
+			out = file
+			closeEvents = func() { file.Close() }
 		}
-	})
-
-	// Extract images
-	doc.Find("img[src]").Each(func(i int, s *goquery.Selection) {
-		src, exists := s.Attr("src")
-		if !exists {
-			return
-		}
-
-		cleanURL := ls.normalizeURL(src, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
-		}
-	})
-
-	// Extract scripts
-	doc.Find("script[src]").Each(func(i int, s *goquery.Selection) {
-		src, exists := s.Attr("src")
-		if !exists {
-			return
-		}
-
-		cleanURL := ls.normalizeURL(src, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
-		}
-	})
-
-	// Extract stylesheets from link tags
-	doc.Find("link[rel='stylesheet']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
+		events = scraper.NewNDJSONReporter(out)
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown events format %q (supported: ndjson)", opts.Events)
+	}
 
-		cleanURL := ls.normalizeURL(href, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
-		}
-	})
+	var display scraper.Reporter
+	var progress *scraper.ProgressReporter
+	if opts.Progress {
+		progress = scraper.NewProgressReporter(console)
+		display = progress
+	} else {
+		display = scraper.NewConsoleReporter(console)
+	}
+	if events == nil {
+		return display, progress, closeEvents, nil
+	}
+	return scraper.MultiReporter(display, events), progress, closeEvents, nil
+}
 
-	// Extract video and audio sources
-	doc.Find("video source[src], audio source[src]").Each(func(i int, s *goquery.Selection) {
-		src, exists := s.Attr("src")
-		if !exists {
-			return
+// parseDownloadOptions reads the -download, -max-file-size and -max-total-size values
+func parseDownloadOptions(categories []string, maxFileSize, maxTotalSize string) (*scraper.DownloadOptions, error) {
+	opts := &scraper.DownloadOptions{}
+	for _, name := range categories {
+		category, ok := scraper.ParseCategory(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", name)
 		}
+		opts.Categories = append(opts.Categories, category)
+	}
 
-		cleanURL := ls.normalizeURL(src, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
-		}
-	})
+	var err error
+	if opts.MaxFileSize, err = parseSize(maxFileSize); err != nil {
+		return nil, err
+	}
+	if opts.MaxTotalSize, err = parseSize(maxTotalSize); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
 
-	// Extract iframe sources
-	doc.Find("iframe[src]").Each(func(i int, s *goquery.Selection) {
-		src, exists := s.Attr("src")
-		if !exists {
-			return
+// seedList gathers the URLs to crawl: -url (or the first positional parameter),
+// the urls of the config file (or the next positional parameters) and the
+// URLs listed in the -url-file file, without duplicates
+func seedList(opts options) ([]string, error) {
+	seeds := append([]string{opts.URL}, opts.URLs...)
+	if opts.URLFile != "" {
+		listed, err := readList(opts.URLFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading URL file: %v", err)
 		}
+		seeds = append(seeds, listed...)
+	}
 
-		cleanURL := ls.normalizeURL(src, targetURL)
-		if cleanURL != "" {
-			ls.addLink(cleanURL)
-			linkCount++
+	unique := make([]string, 0, len(seeds))
+	seen := make(map[string]bool)
+	for _, seed := range seeds {
+		if seed != "" && !seen[seed] {
+			seen[seed] = true
+			unique = append(unique, seed)
 		}
-	})
-
-	fmt.Printf("📊 Total of %d links found on this page\n", linkCount)
-	return newInternalLinks, nil
+	}
+	return unique, nil
 }
 
-func (ls *LinkScraper) isInternalLink(link string) bool {
-	parsedLink, err := url.Parse(link)
+// readList reads a file with one value per line. Empty lines and lines
+// starting with # are ignored.
+func readList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return false
+		return nil, err
 	}
-
-	// If no host, it's a relative link, thus internal
-	if parsedLink.Host == "" {
-		return true
+	var values []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			values = append(values, line)
+		}
 	}
-
-	// Compare domains (with and without www)
-	baseHost := strings.ToLower(ls.baseURL.Host)
-	linkHost := strings.ToLower(parsedLink.Host)
-
-	// Remove www. for comparison
-	baseHost = strings.TrimPrefix(baseHost, "www.")
-	linkHost = strings.TrimPrefix(linkHost, "www.")
-
-	return baseHost == linkHost
+	return values, nil
 }
 
-func (ls *LinkScraper) normalizeURL(href, baseURL string) string {
-	// Clean the href
-	href = strings.TrimSpace(href)
-
-	// Ignore empty links, anchors, javascript, and mailto
-	if href == "" || strings.HasPrefix(href, "#") ||
-		strings.HasPrefix(href, "javascript:") ||
-		strings.HasPrefix(href, "mailto:") ||
-		strings.HasPrefix(href, "tel:") ||
-		strings.HasPrefix(href, "ftp:") ||
-		strings.HasPrefix(href, "file:") ||
-		strings.HasPrefix(href, "data:") {
-		return ""
+// proxyList gathers the -proxy value and the proxies listed in the -proxy-list
+// file, one per line (empty lines and lines starting with # are ignored)
+func proxyList(proxy, listFile string) ([]string, error) {
+	var proxies []string
+	if proxy != "" {
+		proxies = append(proxies, proxy)
 	}
-
-	// Parse the base URL
-	base, err := url.Parse(baseURL)
-	if err != nil {
-		return ""
+	if listFile == "" {
+		return proxies, nil
 	}
 
-	// Parse the href link
-	link, err := url.Parse(href)
+	listed, err := readList(listFile)
 	if err != nil {
-		return ""
+		return nil, fmt.Errorf("error reading proxy list: %v", err)
 	}
-
-	// Resolve the relative URL against the base
-	resolved := base.ResolveReference(link)
-
-	// Clean the URL (remove fragments and unnecessary parameters)
-	resolved.Fragment = ""
-
-	// Remove common tracking parameters
-	query := resolved.Query()
-	trackingParams := []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "fbclid", "gclid"}
-	for _, param := range trackingParams {
-		query.Del(param)
+	proxies = append(proxies, listed...)
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("no proxy found in %s", listFile)
 	}
-	resolved.RawQuery = query.Encode()
-
-	return resolved.String()
+	return proxies, nil
 }
 
-func (ls *LinkScraper) SaveResults() error {
-	return ls.SaveClassifiedResults()
-}
-
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run get-links <URL> [max_depth] [output_folder]")
-		fmt.Println("Example: go run get-links https://example.com 2 ./results")
-		fmt.Println("Parameters:")
-		fmt.Println("  URL: The URL of the website to scrape")
-		fmt.Println("  max_depth: Maximum depth for recursive scraping (default: 1)")
-		fmt.Println("  output_folder: Folder to save results (default: ./scraping_results)")
-		os.Exit(1)
-	}
-
-	targetURL := os.Args[1]
-	maxDepth := 1
-	outputDir := "./scraping_results"
-
-	// Parse max depth if provided
-	if len(os.Args) > 2 {
-		fmt.Sscanf(os.Args[2], "%d", &maxDepth)
-	}
-
-	// Parse output directory if provided
-	if len(os.Args) > 3 {
-		outputDir = os.Args[3]
+// parseSize reads a size such as "500", "200KB", "10MB" or "1GB" (0 when empty)
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.factor
+			value = strings.TrimSuffix(value, unit.suffix)
+			break
+		}
 	}
 
-	fmt.Printf("🚀 Starting ultra-fast scraping of: %s\n", targetURL)
-	fmt.Printf("📊 Maximum depth: %d\n", maxDepth)
-	fmt.Printf("💾 Output directory: %s\n", outputDir)
-	fmt.Println(strings.Repeat("-", 50))
-
-	// Create the scraper
-	scraper, err := NewLinkScraper(targetURL, maxDepth, outputDir)
-	if err != nil {
-		log.Fatalf("❌ Error creating scraper: %v", err)
+	size, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
 	}
+	return size * multiplier, nil
+}
 
-	// Initial connection test
-	fmt.Printf("🔗 Testing connection to %s...\n", targetURL)
-	resp, err := http.Head(targetURL)
-	if err == nil {
-		resp.Body.Close()
-		fmt.Printf("✅ Connection successful (Status: %d)\n", resp.StatusCode)
-	} else {
-		fmt.Printf("⚠️  Connection test failed, but continuing: %v\n", err)
+// outputFormats converts the -output-format values, the scraper validates each format
+func outputFormats(names []string) []scraper.OutputFormat {
+	formats := make([]scraper.OutputFormat, 0, len(names))
+	for _, name := range names {
+		formats = append(formats, scraper.OutputFormat(strings.ToLower(name)))
 	}
+	return formats
+}
 
-	// Start recursive scraping
-	scraper.ScrapeLinksRecursive(targetURL, 0)
-
-	// Save results
-	err = scraper.SaveResults()
-	if err != nil {
-		fmt.Printf("⚠️  Error saving results: %v\n", err)
+func reportFormats(names []string) []scraper.ReportFormat {
+	formats := make([]scraper.ReportFormat, 0, len(names))
+	for _, name := range names {
+		formats = append(formats, scraper.ReportFormat(strings.ToLower(name)))
 	}
-
-	// Print detailed statistics
-	scraper.PrintDetailedStats()
-
-	fmt.Printf("\n✅ Scraping completed successfully!\n")
+	return formats
 }
@@ -0,0 +1,522 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchOptions tells how the seeds of a Batch are crawled and saved
+type BatchOptions struct {
+	Parallel int // seeds crawled at the same time (default: 1)
+
+	// Combined saves a single report for every seed, with figures per domain,
+	// instead of one session directory per seed
+	Combined bool
+}
+
+// DomainSummary holds the figures of one domain in a combined report
+type DomainSummary struct {
+	Domain          string               `json:"domain"`
+	Seeds           []string             `json:"seeds"`
+	PagesVisited    int                  `json:"pages_visited"`
+	TotalLinks      int                  `json:"total_links"`
+	InternalCount   int                  `json:"internal_count"`
+	ExternalCount   int                  `json:"external_count"`
+	ErrorsCount     int                  `json:"errors_count"`
+	FailedCount     int                  `json:"failed_count"`
+	CategorySummary map[LinkCategory]int `json:"category_summary"`
+}
+
+// Batch crawls several websites with the same configuration, one LinkScraper per seed URL
+type Batch struct {
+	scrapers      []*LinkScraper
+	parallel      int
+	combined      bool
+	outputDir     string
+	outputFormats []OutputFormat
+	reports       []ReportFormat
+	reporter      Reporter
+	startTime     time.Time
+	sessionDir    string
+	sessionMutex  sync.Mutex
+	metrics       *Metrics
+	phase         string // see batchPhases
+	phaseMutex    sync.Mutex
+}
+
+// The steps of a run, in order, as reported by the monitoring endpoints
+var batchPhases = []string{"starting", "crawling", "classifying", "checking_links", "downloading", "finished"}
+
+func (b *Batch) setPhase(phase string) {
+	b.phaseMutex.Lock()
+	defer b.phaseMutex.Unlock()
+	b.phase = phase
+}
+
+func (b *Batch) getPhase() string {
+	b.phaseMutex.Lock()
+	defer b.phaseMutex.Unlock()
+	return b.phase
+}
+
+// seedReporter tags the events of a seed with its URL
+type seedReporter struct {
+	reporter Reporter
+	seed     string
+}
+
+func (r seedReporter) Report(event Event) {
+	event.Seed = r.seed
+	r.reporter.Report(event)
+}
+
+// NewBatch creates a scraper for each seed from config, whose BaseURL is ignored.
+// With several seeds, each one saves its state in <OutputDir>/state_<host>.json,
+// their events are tagged with the seed, and they share the rate limiter so that
+// the limits apply to the whole batch.
+func NewBatch(config Config, seeds []string, opts BatchOptions) (*Batch, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no seed URL given")
+	}
+	if len(seeds) > 1 && (config.Resume != nil || config.StateFile != "") {
+		return nil, fmt.Errorf("a state file can only be used with a single seed URL")
+	}
+
+	reporter := config.Reporter
+	if reporter == nil {
+		reporter = NewConsoleReporter(os.Stdout)
+	}
+	b := &Batch{
+		parallel:  max(opts.Parallel, 1),
+		combined:  opts.Combined && len(seeds) > 1,
+		outputDir: config.OutputDir,
+		reporter:  reporter,
+		startTime: time.Now(),
+		metrics:   config.Metrics,
+		phase:     "starting",
+	}
+
+	// The seeds share a cookie jar, so that a single login is enough
+	if config.Client == nil && config.Jar == nil {
+		config.Jar = NewCookieJar()
+	}
+
+	stateNames := make(map[string]int)
+	for _, seed := range seeds {
+		seedConfig := config
+		seedConfig.BaseURL = seed
+		seedConfig.Reporter = reporter
+		if len(seeds) > 1 {
+			seedConfig.Reporter = seedReporter{reporter: reporter, seed: seed}
+		}
+
+		ls, err := New(seedConfig)
+		if err != nil {
+			return nil, fmt.Errorf("seed %s: %v", seed, err)
+		}
+		if len(b.scrapers) > 0 {
+			ls.limiter = b.scrapers[0].limiter
+			ls.contentTypes = b.scrapers[0].contentTypes
+		}
+		if len(seeds) > 1 && config.OutputDir != "" {
+			name := hostFileName(ls.baseURL.Host)
+			stateNames[name]++
+			if n := stateNames[name]; n > 1 {
+				name = fmt.Sprintf("%s_%d", name, n)
+			}
+			ls.stateFile = filepath.Join(config.OutputDir, "state_"+name+".json")
+		}
+		b.scrapers = append(b.scrapers, ls)
+	}
+	b.outputFormats = b.scrapers[0].outputFormats
+	b.reports = b.scrapers[0].reports
+	return b, nil
+}
+
+// Scrapers returns the scraper of each seed, in the order of the seeds
+func (b *Batch) Scrapers() []*LinkScraper {
+	return b.scrapers
+}
+
+func (b *Batch) logf(format string, args ...any) {
+	b.reporter.Report(Event{Type: EventMessage, Time: time.Now(), Message: fmt.Sprintf(format, args...)})
+}
+
+// Scrape crawls the seeds, Parallel at a time. Once ctx is cancelled, the
+// remaining seeds are not crawled but their state is saved all the same, so
+// that each of them can be resumed.
+func (b *Batch) Scrape(ctx context.Context) error {
+	b.setPhase("crawling")
+	seeds := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(b.parallel, len(b.scrapers)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range seeds {
+				ls := b.scrapers[index]
+				if len(b.scrapers) > 1 && ctx.Err() == nil {
+					ls.logf("🌱 Seed %d/%d: %s", index+1, len(b.scrapers), ls.baseURL)
+				}
+				ls.Scrape(ctx)
+			}
+		}()
+	}
+	for index := range b.scrapers {
+		seeds <- index
+	}
+	close(seeds)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// ClassifyByContentType verifies the doubtful links of every seed, see
+// LinkScraper.ClassifyByContentType. A link found on several seeds is requested once.
+func (b *Batch) ClassifyByContentType(ctx context.Context) {
+	b.setPhase("classifying")
+	for _, ls := range b.scrapers {
+		if ctx.Err() != nil {
+			return
+		}
+		ls.ClassifyByContentType(ctx)
+	}
+}
+
+// Login submits the login form once for all the seeds, see LinkScraper.Login
+func (b *Batch) Login(ctx context.Context, step LoginStep) error {
+	return b.scrapers[0].Login(ctx, step)
+}
+
+// CheckLinks checks the links of every seed, see LinkScraper.CheckLinks
+func (b *Batch) CheckLinks(ctx context.Context) {
+	b.setPhase("checking_links")
+	for _, ls := range b.scrapers {
+		if ctx.Err() != nil {
+			return
+		}
+		ls.CheckLinks(ctx)
+	}
+}
+
+// DownloadAssets downloads the files of every seed, see LinkScraper.DownloadAssets.
+// In a combined batch, each seed downloads into a directory of the combined report.
+func (b *Batch) DownloadAssets(ctx context.Context, opts DownloadOptions) error {
+	b.setPhase("downloading")
+	for _, ls := range b.scrapers {
+		if ctx.Err() != nil {
+			return nil
+		}
+		var err error
+		if b.combined {
+			err = b.downloadCombined(ctx, ls, opts)
+		} else {
+			err = ls.DownloadAssets(ctx, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("seed %s: %v", ls.baseURL, err)
+		}
+	}
+	return nil
+}
+
+// downloadCombined downloads the files of a seed into its own directory of the combined report
+func (b *Batch) downloadCombined(ctx context.Context, ls *LinkScraper, opts DownloadOptions) error {
+	batchDir, err := b.SessionDir()
+	if err != nil {
+		return err
+	}
+	seedDir, err := newSessionDir(batchDir, hostFileName(ls.baseURL.Host))
+	if err != nil {
+		return err
+	}
+	return ls.downloadAssets(ctx, opts, seedDir)
+}
+
+// SessionDir returns the directory of the combined report, created on first use
+func (b *Batch) SessionDir() (string, error) {
+	b.sessionMutex.Lock()
+	defer b.sessionMutex.Unlock()
+
+	if b.sessionDir != "" {
+		return b.sessionDir, nil
+	}
+	if b.outputDir == "" {
+		return "", fmt.Errorf("no output directory configured")
+	}
+	sessionDir, err := newSessionDir(b.outputDir, "batch")
+	if err != nil {
+		return "", err
+	}
+	b.sessionDir = sessionDir
+	return sessionDir, nil
+}
+
+// SaveResults saves the combined report, or the results of each seed in its
+// own session directory. It is the last step of a run.
+func (b *Batch) SaveResults() error {
+	defer b.setPhase("finished")
+	if b.outputDir == "" {
+		return nil
+	}
+	if !b.combined {
+		for _, ls := range b.scrapers {
+			if err := ls.SaveResults(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sessionDir, err := b.SessionDir()
+	if err != nil {
+		return err
+	}
+	if err := saveResults(sessionDir, b.outputFormats, b.reports, b.Results()); err != nil {
+		return err
+	}
+	b.logf("💾 Results saved to: %s", sessionDir)
+	return nil
+}
+
+// PrintDetailedStats prints the combined statistics, or those of each seed
+// followed by a summary per domain, to out
+func (b *Batch) PrintDetailedStats(out io.Writer) {
+	if b.combined || len(b.scrapers) == 1 {
+		printDetailedStats(out, b.Results())
+		return
+	}
+	for _, ls := range b.scrapers {
+		ls.PrintDetailedStats(out)
+	}
+	printDomains(out, b.Results().Domains)
+}
+
+// Progress sums up the progress of the seeds
+func (b *Batch) Progress() Progress {
+	if len(b.scrapers) == 1 {
+		return b.scrapers[0].Progress()
+	}
+
+	var total Progress
+	for _, ls := range b.scrapers {
+		progress := ls.Progress()
+		total.PagesVisited += progress.PagesVisited
+		total.Queued += progress.Queued
+		total.Links += progress.Links
+		total.Errors += progress.Errors
+		total.Requests += progress.Requests
+		total.pagesThisRun += progress.pagesThisRun
+	}
+	total.Elapsed = time.Since(b.startTime)
+	return total
+}
+
+// Results returns the results of a single seed as is. With several seeds,
+// they are combined: links are listed once, figures are summed, and the
+// Domains section gives the figures of each domain.
+func (b *Batch) Results() ScrapingResults {
+	if len(b.scrapers) == 1 {
+		return b.scrapers[0].Results()
+	}
+
+	parts := make([]ScrapingResults, 0, len(b.scrapers))
+	for _, ls := range b.scrapers {
+		parts = append(parts, ls.Results())
+	}
+	return mergeResults(parts, time.Since(b.startTime))
+}
+
+// mergeResults combines the results of several crawls into one report
+func mergeResults(parts []ScrapingResults, elapsed time.Duration) ScrapingResults {
+	merged := ScrapingResults{
+		InternalLinks:   make([]string, 0),
+		ExternalLinks:   make([]string, 0),
+		AllLinks:        make([]string, 0),
+		ClassifiedLinks: make(map[LinkCategory][]ClassifiedLink),
+		CategorySummary: make(map[LinkCategory]int),
+		Errors:          make([]string, 0),
+		DisallowedURLs:  make([]string, 0),
+		RedirectedPages: make([]RedirectedPage, 0),
+		DuplicatePages:  make([]DuplicatePage, 0),
+		CollapsedLinks:  make([]CollapsedLink, 0),
+		RetriedURLs:     make([]string, 0),
+		FailedURLs:      make([]FailedURL, 0),
+		Timestamp:       time.Now().Format("2006-01-02 15:04:05"),
+	}
+	for _, category := range Categories {
+		merged.ClassifiedLinks[category] = make([]ClassifiedLink, 0)
+	}
+
+	// A link internal to one seed and external to another is counted as internal
+	internal := make(map[string]bool)
+	for _, part := range parts {
+		for _, link := range part.InternalLinks {
+			if !internal[link] {
+				internal[link] = true
+				merged.InternalLinks = append(merged.InternalLinks, link)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	external := make(map[string]bool)
+	stats := &merged.Statistics
+	proxies := make(map[string]int) // proxy URL -> index in stats.Proxies
+	for _, part := range parts {
+		merged.Seeds = append(merged.Seeds, part.BaseURL)
+
+		for _, link := range part.AllLinks {
+			if !seen[link] {
+				seen[link] = true
+				merged.AllLinks = append(merged.AllLinks, link)
+			}
+		}
+		for _, link := range part.ExternalLinks {
+			if !internal[link] && !external[link] {
+				external[link] = true
+				merged.ExternalLinks = append(merged.ExternalLinks, link)
+			}
+		}
+		for category, links := range part.ClassifiedLinks {
+			merged.ClassifiedLinks[category] = append(merged.ClassifiedLinks[category], links...)
+		}
+
+		merged.Errors = append(merged.Errors, part.Errors...)
+		merged.DisallowedURLs = append(merged.DisallowedURLs, part.DisallowedURLs...)
+		merged.RedirectedPages = append(merged.RedirectedPages, part.RedirectedPages...)
+		merged.DuplicatePages = append(merged.DuplicatePages, part.DuplicatePages...)
+		merged.CollapsedLinks = append(merged.CollapsedLinks, part.CollapsedLinks...)
+		merged.RetriedURLs = append(merged.RetriedURLs, part.RetriedURLs...)
+		merged.FailedURLs = append(merged.FailedURLs, part.FailedURLs...)
+		merged.CheckedLinks = append(merged.CheckedLinks, part.CheckedLinks...)
+		merged.Sitemaps = append(merged.Sitemaps, part.Sitemaps...)
+		merged.URLSources.Sitemap += part.URLSources.Sitemap
+		merged.URLSources.HTML += part.URLSources.HTML
+
+		stats.PagesVisited += part.Statistics.PagesVisited
+		stats.RedirectLoops += part.Statistics.RedirectLoops
+		stats.Retries += part.Statistics.Retries
+		stats.MaxDepthReached = max(stats.MaxDepthReached, part.Statistics.MaxDepthReached)
+		if check := part.Statistics.LinkCheck; check != nil {
+			if stats.LinkCheck == nil {
+				stats.LinkCheck = &LinkCheckStats{}
+			}
+			stats.LinkCheck.Checked += check.Checked
+			stats.LinkCheck.Status2xx += check.Status2xx
+			stats.LinkCheck.Status3xx += check.Status3xx
+			stats.LinkCheck.Status4xx += check.Status4xx
+			stats.LinkCheck.Status5xx += check.Status5xx
+			stats.LinkCheck.Timeouts += check.Timeouts
+			stats.LinkCheck.Errors += check.Errors
+			stats.LinkCheck.Disallowed += check.Disallowed
+			stats.LinkCheck.Broken += check.Broken
+			stats.LinkCheck.Partial = stats.LinkCheck.Partial || check.Partial
+		}
+		if contentTypes := part.Statistics.ContentTypes; contentTypes != nil {
+			if stats.ContentTypes == nil {
+				stats.ContentTypes = &ContentTypeStats{}
+			}
+			stats.ContentTypes.Checked += contentTypes.Checked
+			stats.ContentTypes.Reclassified += contentTypes.Reclassified
+			stats.ContentTypes.Unknown += contentTypes.Unknown
+			stats.ContentTypes.Failed += contentTypes.Failed
+			stats.ContentTypes.Disallowed += contentTypes.Disallowed
+		}
+		if downloads := part.Statistics.Downloads; downloads != nil {
+			if stats.Downloads == nil {
+				stats.Downloads = &DownloadStats{}
+			}
+			stats.Downloads.Downloaded += downloads.Downloaded
+			stats.Downloads.Skipped += downloads.Skipped
+			stats.Downloads.Failed += downloads.Failed
+			stats.Downloads.TotalBytes += downloads.TotalBytes
+		}
+		if filters := part.Statistics.Filters; filters != nil {
+			if stats.Filters == nil {
+				stats.Filters = &FilterStats{}
+			}
+			stats.Filters.Excluded += filters.Excluded
+			stats.Filters.NotIncluded += filters.NotIncluded
+			stats.Filters.OutOfScope += filters.OutOfScope
+		}
+		// Every seed has its own pool of the same proxies
+		for _, proxy := range part.Statistics.Proxies {
+			index, exists := proxies[proxy.URL]
+			if !exists {
+				proxies[proxy.URL] = len(stats.Proxies)
+				stats.Proxies = append(stats.Proxies, proxy)
+				continue
+			}
+			stats.Proxies[index].Requests += proxy.Requests
+			stats.Proxies[index].Failures += proxy.Failures
+			stats.Proxies[index].Benched += proxy.Benched
+		}
+	}
+
+	// The same link found from several seeds is listed once, with its first origin
+	listed := make(map[string]bool)
+	for category, links := range merged.ClassifiedLinks {
+		unique := make([]ClassifiedLink, 0, len(links))
+		for _, link := range links {
+			if !listed[link.URL] {
+				listed[link.URL] = true
+				unique = append(unique, link)
+			}
+		}
+		merged.ClassifiedLinks[category] = unique
+		merged.CategorySummary[category] = len(unique)
+	}
+
+	merged.TotalLinks = len(merged.AllLinks)
+	merged.Domains = domainSummaries(parts)
+	stats.TotalLinks = merged.TotalLinks
+	stats.InternalCount = len(merged.InternalLinks)
+	stats.ExternalCount = len(merged.ExternalLinks)
+	stats.ErrorsCount = len(merged.Errors)
+	stats.DisallowedCount = len(merged.DisallowedURLs)
+	stats.RedirectedCount = len(merged.RedirectedPages)
+	stats.DuplicateCount = len(merged.DuplicatePages)
+	stats.CollapsedCount = len(merged.CollapsedLinks)
+	stats.RetriedCount = len(merged.RetriedURLs)
+	stats.FailedCount = len(merged.FailedURLs)
+	stats.ExecutionTime = elapsed.String()
+	return merged
+}
+
+// domainSummaries sums up the results per domain, in the order of the seeds
+func domainSummaries(parts []ScrapingResults) []DomainSummary {
+	domains := make([]DomainSummary, 0)
+	indexes := make(map[string]int)
+	for _, part := range parts {
+		domain := part.BaseURL
+		if parsedURL, err := url.Parse(part.BaseURL); err == nil && parsedURL.Host != "" {
+			domain = strings.ToLower(parsedURL.Host)
+		}
+
+		index, exists := indexes[domain]
+		if !exists {
+			index = len(domains)
+			indexes[domain] = index
+			domains = append(domains, DomainSummary{Domain: domain, CategorySummary: make(map[LinkCategory]int)})
+		}
+		summary := &domains[index]
+		summary.Seeds = append(summary.Seeds, part.BaseURL)
+		summary.PagesVisited += part.Statistics.PagesVisited
+		summary.TotalLinks += part.Statistics.TotalLinks
+		summary.InternalCount += part.Statistics.InternalCount
+		summary.ExternalCount += part.Statistics.ExternalCount
+		summary.ErrorsCount += part.Statistics.ErrorsCount
+		summary.FailedCount += part.Statistics.FailedCount
+		for category, count := range part.CategorySummary {
+			summary.CategorySummary[category] += count
+		}
+	}
+	return domains
+}
@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMergeResults(t *testing.T) {
+	first := ScrapingResults{
+		BaseURL:       "https://example.com/",
+		AllLinks:      []string{"https://example.com/a", "https://other.example/"},
+		InternalLinks: []string{"https://example.com/a"},
+		ExternalLinks: []string{"https://other.example/"},
+		ClassifiedLinks: map[LinkCategory][]ClassifiedLink{
+			CategoryHTML: {{URL: "https://example.com/a", SourcePage: "https://example.com/"}, {URL: "https://other.example/"}},
+		},
+		Errors: []string{"first error"},
+		Statistics: ScrapingStats{
+			PagesVisited:    2,
+			TotalLinks:      2,
+			InternalCount:   1,
+			ExternalCount:   1,
+			ErrorsCount:     1,
+			MaxDepthReached: 1,
+			LinkCheck:       &LinkCheckStats{Checked: 2, Broken: 1},
+			Proxies:         []ProxyStats{{URL: "http://proxy:8080", Requests: 3, Failures: 1}},
+		},
+	}
+	second := ScrapingResults{
+		BaseURL:       "https://other.example/",
+		AllLinks:      []string{"https://other.example/", "https://example.com/a"},
+		InternalLinks: []string{"https://other.example/"},
+		ExternalLinks: []string{"https://example.com/a"},
+		ClassifiedLinks: map[LinkCategory][]ClassifiedLink{
+			CategoryHTML: {{URL: "https://other.example/"}, {URL: "https://example.com/a", SourcePage: "https://other.example/"}},
+		},
+		Statistics: ScrapingStats{
+			PagesVisited:    3,
+			TotalLinks:      2,
+			InternalCount:   1,
+			ExternalCount:   1,
+			MaxDepthReached: 2,
+			Filters:         &FilterStats{Excluded: 4},
+			Proxies:         []ProxyStats{{URL: "http://proxy:8080", Requests: 2}, {URL: "http://other-proxy:8080", Requests: 1}},
+		},
+	}
+	third := second
+	third.BaseURL = "https://OTHER.example/blog/"
+	third.Statistics.Proxies = nil
+	merged := mergeResults([]ScrapingResults{first, second, third}, time.Second)
+
+	if want := []string{"https://example.com/", "https://other.example/", "https://OTHER.example/blog/"}; !slices.Equal(merged.Seeds, want) {
+		t.Errorf("seeds = %q, want %q", merged.Seeds, want)
+	}
+	if want := []string{"https://example.com/a", "https://other.example/"}; !slices.Equal(merged.AllLinks, want) {
+		t.Errorf("links = %q, want each link once: %q", merged.AllLinks, want)
+	}
+	// A link internal to one of the seeds is internal to the batch
+	if want := []string{"https://example.com/a", "https://other.example/"}; !slices.Equal(merged.InternalLinks, want) || len(merged.ExternalLinks) != 0 {
+		t.Errorf("internal links = %q and external links = %q, want every link internal", merged.InternalLinks, merged.ExternalLinks)
+	}
+	html := merged.ClassifiedLinks[CategoryHTML]
+	if len(html) != 2 || html[0].SourcePage != "https://example.com/" || merged.CategorySummary[CategoryHTML] != 2 {
+		t.Errorf("classified pages = %+v, want each link once with its first origin", html)
+	}
+	if images, ok := merged.ClassifiedLinks[CategoryImage]; !ok || images == nil {
+		t.Error("empty categories missing from the combined report")
+	}
+
+	stats := merged.Statistics
+	if stats.PagesVisited != 8 || stats.TotalLinks != 2 || stats.ErrorsCount != 1 || stats.MaxDepthReached != 2 {
+		t.Errorf("statistics = %+v, want 8 pages, 2 links, 1 error and depth 2", stats)
+	}
+	if stats.LinkCheck == nil || *stats.LinkCheck != (LinkCheckStats{Checked: 2, Broken: 1}) {
+		t.Errorf("link check = %+v, want the figures of the only checked seed", stats.LinkCheck)
+	}
+	if stats.Filters == nil || stats.Filters.Excluded != 8 {
+		t.Errorf("filters = %+v, want 8 excluded", stats.Filters)
+	}
+	if stats.ContentTypes != nil || stats.Downloads != nil {
+		t.Error("statistics of passes that never ran are set")
+	}
+	wantProxies := []ProxyStats{{URL: "http://proxy:8080", Requests: 5, Failures: 1}, {URL: "http://other-proxy:8080", Requests: 1}}
+	if !slices.Equal(stats.Proxies, wantProxies) {
+		t.Errorf("proxies = %+v, want %+v", stats.Proxies, wantProxies)
+	}
+
+	// The domains are compared without their case
+	if len(merged.Domains) != 2 {
+		t.Fatalf("domains = %+v, want 2", merged.Domains)
+	}
+	other := merged.Domains[1]
+	if other.Domain != "other.example" || len(other.Seeds) != 2 || other.PagesVisited != 6 || other.TotalLinks != 4 {
+		t.Errorf("domain = %+v, want the 2 seeds of other.example summed", other)
+	}
+}
+
+func TestBatchCombinedReport(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<a href="/">Home</a>`)
+	}))
+	t.Cleanup(other.Close)
+	var site *httptest.Server
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/a/", "/b/":
+			fmt.Fprintf(w, `<a href="/shared.html">Shared</a><a href="%s%spage.html">Page</a><a href="%s/">Other</a>`, site.URL, r.URL.Path, other.URL)
+		default:
+			io.WriteString(w, `<p>page</p>`)
+		}
+	}))
+	t.Cleanup(site.Close)
+
+	outputDir := t.TempDir()
+	seeds := []string{site.URL + "/a/", site.URL + "/b/", other.URL + "/"}
+	b, err := NewBatch(Config{
+		MaxDepth:  1,
+		OutputDir: outputDir,
+		Reporter:  NewConsoleReporter(io.Discard),
+	}, seeds, BatchOptions{Parallel: 2, Combined: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Scrape(context.Background())
+	if err := b.SaveResults(); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionDir, err := b.SessionDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(sessionDir, "summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results ScrapingResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(results.Seeds, seeds) {
+		t.Errorf("seeds = %q, want %q", results.Seeds, seeds)
+	}
+	// The seed of other is internal to the batch, though external to the others
+	if len(results.ExternalLinks) != 0 {
+		t.Errorf("external links = %q, want none", results.ExternalLinks)
+	}
+	if results.TotalLinks != 4 || results.Statistics.PagesVisited != 7 {
+		t.Errorf("%d links and %d pages, want 4 and 7", results.TotalLinks, results.Statistics.PagesVisited)
+	}
+	siteURL, _ := url.Parse(site.URL)
+	if len(results.Domains) != 2 || results.Domains[0].Domain != siteURL.Host || len(results.Domains[0].Seeds) != 2 {
+		t.Errorf("domains = %+v, want the 2 seeds of %s together", results.Domains, siteURL.Host)
+	}
+
+	// Each seed saves its state, the seeds of a same host under distinct names
+	name := hostFileName(siteURL.Host)
+	for _, file := range []string{"state_" + name + ".json", "state_" + name + "_2.json"} {
+		if _, err := LoadState(filepath.Join(outputDir, file)); err != nil {
+			t.Errorf("state of a seed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// CanonicalRule rewrites a URL into a more canonical form. URLs with the same
+// canonical form are the same link: they are recorded and crawled once. The
+// canonical form is only used to compare URLs, the recorded URL is the first
+// one found, so that it always is a URL the website actually links to.
+type CanonicalRule func(u *url.URL)
+
+// StripFragment removes the #fragment, which never changes the page
+func StripFragment(u *url.URL) {
+	u.Fragment = ""
+	u.RawFragment = ""
+}
+
+// LowercaseHost lowercases the scheme and the host, which are case-insensitive
+func LowercaseHost(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+}
+
+// RemoveDefaultPort removes :80 from http URLs and :443 from https ones
+func RemoveDefaultPort(u *url.URL) {
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+}
+
+// IgnoreScheme treats http and https URLs as the same link. The default port
+// of each scheme is removed too, so that http://h:80 and https://h:443 match.
+func IgnoreScheme(u *url.URL) {
+	if u.Scheme == "http" {
+		RemoveDefaultPort(u)
+		u.Scheme = "https"
+	}
+	RemoveDefaultPort(u)
+}
+
+// RemoveTrailingSlash treats /docs/ and /docs as the same link. The root path is kept.
+func RemoveTrailingSlash(u *url.URL) {
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+}
+
+// Directory index pages, served for the URL of their directory
+var indexFiles = []string{"index.html", "index.htm", "index.php", "index.asp", "index.aspx", "default.htm", "default.html", "default.asp", "default.aspx"}
+
+// RemoveIndexFile treats /docs/index.html and /docs/ as the same link
+func RemoveIndexFile(u *url.URL) {
+	dir, file := path.Split(u.Path)
+	if slices.Contains(indexFiles, strings.ToLower(file)) {
+		u.Path = dir
+		u.RawPath = ""
+	}
+}
+
+// SortQuery sorts the query parameters, whose order rarely matters
+func SortQuery(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	params := strings.Split(u.RawQuery, "&")
+	slices.Sort(params)
+	u.RawQuery = strings.Join(params, "&")
+}
+
+// StripQueryParams removes the given query parameters (session IDs, sort
+// orders...). A name ending with * removes every parameter with that prefix,
+// and "*" removes the whole query.
+func StripQueryParams(names ...string) CanonicalRule {
+	return func(u *url.URL) {
+		if u.RawQuery == "" {
+			return
+		}
+		params := strings.Split(u.RawQuery, "&")
+		kept := params[:0]
+		for _, param := range params {
+			name, _, _ := strings.Cut(param, "=")
+			if decoded, err := url.QueryUnescape(name); err == nil {
+				name = decoded
+			}
+			if !matchesParam(name, names) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+}
+
+func matchesParam(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultCanonicalRules are used when Config.CanonicalRules is not set
+var DefaultCanonicalRules = []CanonicalRule{StripFragment, LowercaseHost, RemoveDefaultPort}
+
+// The rules that can be selected by name, in the order they are applied
+var namedCanonicalRules = []struct {
+	name string
+	rule CanonicalRule
+}{
+	{"strip-fragment", StripFragment},
+	{"lowercase-host", LowercaseHost},
+	{"ignore-scheme", IgnoreScheme},
+	{"default-port", RemoveDefaultPort},
+	{"index-file", RemoveIndexFile},
+	{"trailing-slash", RemoveTrailingSlash},
+	{"sort-query", SortQuery},
+}
+
+// CanonicalRuleNames lists the names accepted by ParseCanonicalRules
+func CanonicalRuleNames() []string {
+	names := make([]string, 0, len(namedCanonicalRules))
+	for _, named := range namedCanonicalRules {
+		names = append(names, named.name)
+	}
+	return names
+}
+
+// ParseCanonicalRules returns the default rules along with the named ones
+// (e.g. "trailing-slash"), in the order they must be applied
+func ParseCanonicalRules(names []string) ([]CanonicalRule, error) {
+	selected := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(CanonicalRuleNames(), name) {
+			return nil, fmt.Errorf("unknown canonicalization rule: %q", name)
+		}
+		selected[name] = true
+	}
+	for _, name := range []string{"strip-fragment", "lowercase-host", "default-port"} {
+		selected[name] = true
+	}
+
+	rules := make([]CanonicalRule, 0, len(selected))
+	for _, named := range namedCanonicalRules {
+		if selected[named.name] {
+			rules = append(rules, named.rule)
+		}
+	}
+	return rules, nil
+}
+
+// orderCanonicalRules sorts rules in the order of namedCanonicalRules, whatever
+// the order they were given in, so that a URL always gets the same canonical
+// form. The other rules (StripQueryParams, custom ones) come last, in their order.
+func orderCanonicalRules(rules []CanonicalRule) []CanonicalRule {
+	rank := func(rule CanonicalRule) int {
+		pointer := reflect.ValueOf(rule).Pointer()
+		for i, named := range namedCanonicalRules {
+			if reflect.ValueOf(named.rule).Pointer() == pointer {
+				return i
+			}
+		}
+		return len(namedCanonicalRules)
+	}
+	ordered := slices.Clone(rules)
+	slices.SortStableFunc(ordered, func(a, b CanonicalRule) int {
+		return rank(a) - rank(b)
+	})
+	return ordered
+}
+
+// CollapsedLink is a URL that was not recorded because its canonical form is
+// the one of a link recorded before
+type CollapsedLink struct {
+	URL    string `json:"url"`
+	SameAs string `json:"same_as"` // the recorded link
+}
+
+// canonicalKey returns the canonical form of link, used to deduplicate the
+// links and pages. ls.canonicalRules were sorted by orderCanonicalRules.
+func (ls *LinkScraper) canonicalKey(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	for _, rule := range ls.canonicalRules {
+		rule(u)
+	}
+	return u.String()
+}
@@ -0,0 +1,161 @@
+package scraper
+
+import (
+	"net/url"
+	"testing"
+)
+
+func applyRule(t *testing.T, rule CanonicalRule, link string) string {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule(u)
+	return u.String()
+}
+
+func TestCanonicalRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule CanonicalRule
+		link string
+		want string
+	}{
+		{"strip-fragment", StripFragment, "https://example.com/page#top", "https://example.com/page"},
+		{"strip-fragment without fragment", StripFragment, "https://example.com/page?a=1", "https://example.com/page?a=1"},
+		{"lowercase-host", LowercaseHost, "HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"default-port http", RemoveDefaultPort, "http://example.com:80/", "http://example.com/"},
+		{"default-port https", RemoveDefaultPort, "https://example.com:443/", "https://example.com/"},
+		{"default-port other port", RemoveDefaultPort, "https://example.com:8443/", "https://example.com:8443/"},
+		{"default-port wrong scheme", RemoveDefaultPort, "http://example.com:443/", "http://example.com:443/"},
+		{"ignore-scheme http", IgnoreScheme, "http://example.com/page", "https://example.com/page"},
+		{"ignore-scheme http port 80", IgnoreScheme, "http://example.com:80/page", "https://example.com/page"},
+		{"ignore-scheme http port 443", IgnoreScheme, "http://example.com:443/page", "https://example.com/page"},
+		{"ignore-scheme https port 443", IgnoreScheme, "https://example.com:443/page", "https://example.com/page"},
+		{"ignore-scheme other port", IgnoreScheme, "http://example.com:8080/page", "https://example.com:8080/page"},
+		{"trailing-slash", RemoveTrailingSlash, "https://example.com/docs/", "https://example.com/docs"},
+		{"trailing-slash several", RemoveTrailingSlash, "https://example.com/docs//", "https://example.com/docs"},
+		{"trailing-slash root", RemoveTrailingSlash, "https://example.com/", "https://example.com/"},
+		{"index-file", RemoveIndexFile, "https://example.com/docs/index.html", "https://example.com/docs/"},
+		{"index-file case", RemoveIndexFile, "https://example.com/Default.ASPX", "https://example.com/"},
+		{"index-file other file", RemoveIndexFile, "https://example.com/docs/indexes.html", "https://example.com/docs/indexes.html"},
+		{"sort-query", SortQuery, "https://example.com/?b=2&a=1&c=3", "https://example.com/?a=1&b=2&c=3"},
+		{"sort-query empty", SortQuery, "https://example.com/", "https://example.com/"},
+		{"strip-params", StripQueryParams("sessionid"), "https://example.com/?a=1&sessionid=x&b=2", "https://example.com/?a=1&b=2"},
+		{"strip-params prefix", StripQueryParams("utm_*"), "https://example.com/?utm_source=x&utm_medium=y&id=3", "https://example.com/?id=3"},
+		{"strip-params encoded name", StripQueryParams("session id"), "https://example.com/?session%20id=x&id=3", "https://example.com/?id=3"},
+		{"strip-params whole query", StripQueryParams("*"), "https://example.com/?a=1&b=2", "https://example.com/"},
+		{"strip-params no match", StripQueryParams("sessionid"), "https://example.com/?session=1", "https://example.com/?session=1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := applyRule(t, test.rule, test.link); got != test.want {
+				t.Errorf("%s(%q) = %q, want %q", test.name, test.link, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCanonicalRules(t *testing.T) {
+	rules, err := ParseCanonicalRules(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(DefaultCanonicalRules) {
+		t.Errorf("ParseCanonicalRules(nil) returned %d rules, want the %d default ones", len(rules), len(DefaultCanonicalRules))
+	}
+
+	rules, err = ParseCanonicalRules([]string{" Trailing-Slash ", "sort-query", "trailing-slash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(DefaultCanonicalRules) + 2; len(rules) != want {
+		t.Errorf("ParseCanonicalRules returned %d rules, want %d", len(rules), want)
+	}
+
+	if _, err := ParseCanonicalRules([]string{"trailing-slash", "no-such-rule"}); err == nil {
+		t.Errorf("ParseCanonicalRules accepted an unknown rule")
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	allRules, err := ParseCanonicalRules(CanonicalRuleNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same rules, given in the wrong order
+	reversed := []CanonicalRule{StripQueryParams("sessionid"), SortQuery, RemoveTrailingSlash, RemoveIndexFile, RemoveDefaultPort, IgnoreScheme, LowercaseHost, StripFragment}
+
+	tests := []struct {
+		name  string
+		rules []CanonicalRule
+		links []string // links with the same canonical form
+		want  string
+	}{
+		{
+			name:  "defaults",
+			rules: DefaultCanonicalRules,
+			links: []string{"https://Example.com:443/page#top", "https://example.com/page", "HTTPS://EXAMPLE.COM/page#bottom"},
+			want:  "https://example.com/page",
+		},
+		{
+			name:  "every rule",
+			rules: append(allRules, StripQueryParams("sessionid")),
+			links: []string{
+				"http://example.com/docs/index.html?b=2&a=1",
+				"https://example.com:443/docs/?a=1&b=2&sessionid=42",
+				"http://example.com:80/docs?sessionid=7&b=2&a=1#top",
+				"https://EXAMPLE.com/docs//?b=2&a=1",
+			},
+			want: "https://example.com/docs?a=1&b=2",
+		},
+		{
+			name:  "ignore-scheme and ports",
+			rules: orderCanonicalRules(append([]CanonicalRule{IgnoreScheme}, DefaultCanonicalRules...)),
+			links: []string{"https://example.com:443/", "http://example.com:443/", "http://example.com:80/", "http://example.com/", "https://example.com/"},
+			want:  "https://example.com/",
+		},
+		{
+			name:  "rules in any order",
+			rules: orderCanonicalRules(reversed),
+			links: []string{"http://example.com:80/docs/index.html?sessionid=1&b=2&a=1", "https://example.com/docs?a=1&b=2"},
+			want:  "https://example.com/docs?a=1&b=2",
+		},
+		{
+			name:  "different pages",
+			rules: DefaultCanonicalRules,
+			links: []string{"https://example.com/Page"},
+			want:  "https://example.com/Page",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := &LinkScraper{canonicalRules: test.rules}
+			for _, link := range test.links {
+				if got := ls.canonicalKey(link); got != test.want {
+					t.Errorf("canonicalKey(%q) = %q, want %q", link, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderCanonicalRules(t *testing.T) {
+	stripSession := StripQueryParams("sessionid")
+	ordered := orderCanonicalRules([]CanonicalRule{stripSession, RemoveDefaultPort, IgnoreScheme, StripFragment})
+
+	ls := &LinkScraper{canonicalRules: ordered}
+	if got, want := ls.canonicalKey("http://example.com:80/?sessionid=1#top"), "https://example.com/"; got != want {
+		t.Errorf("canonicalKey = %q, want %q", got, want)
+	}
+	if len(ordered) != 4 {
+		t.Fatalf("orderCanonicalRules returned %d rules, want 4", len(ordered))
+	}
+	if got := applyRule(t, ordered[0], "https://example.com/#top"); got != "https://example.com/" {
+		t.Errorf("first rule is not strip-fragment: %q", got)
+	}
+	if got := applyRule(t, ordered[3], "https://example.com/?sessionid=1"); got != "https://example.com/" {
+		t.Errorf("custom rule is not last: %q", got)
+	}
+}
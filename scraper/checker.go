@@ -0,0 +1,245 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// LinkStatus is the outcome of checking one link
+type LinkStatus struct {
+	URL          string `json:"url"`
+	StatusCode   int    `json:"status_code"`
+	RedirectTo   string `json:"redirect_to,omitempty"`
+	ResponseTime string `json:"response_time"`
+	Timeout      bool   `json:"timeout,omitempty"`
+	Disallowed   bool   `json:"disallowed,omitempty"` // internal link not requested because of robots.txt
+	Error        string `json:"error,omitempty"`
+}
+
+// Broken reports whether the link is unreachable or answered with a 4xx/5xx status
+func (s LinkStatus) Broken() bool {
+	return s.Error != "" || s.StatusCode >= 400
+}
+
+// LinkCheckStats counts the checked links per status class
+type LinkCheckStats struct {
+	Checked    int `json:"checked"`
+	Status2xx  int `json:"status_2xx"`
+	Status3xx  int `json:"status_3xx"`
+	Status4xx  int `json:"status_4xx"`
+	Status5xx  int `json:"status_5xx"`
+	Timeouts   int `json:"timeouts"`
+	Errors     int `json:"errors"`
+	Disallowed int `json:"disallowed"` // not checked because of robots.txt
+	Broken     int `json:"broken"`
+
+	// Partial is set when the check was interrupted: only the links checked
+	// until then are counted
+	Partial bool `json:"partial,omitempty"`
+}
+
+// CheckLinks requests every discovered link, internal and external, and records
+// its status code, redirect target and response time. A HEAD request is tried
+// first, falling back to GET for servers that don't support it.
+// Redirects are not followed so that their target can be reported.
+// Internal links are checked against robots.txt and paced like the crawl;
+// external ones only wait for the rate limiter. When ctx is cancelled, the links checked until then are kept and the
+// statistics are marked as partial.
+func (ls *LinkScraper) CheckLinks(ctx context.Context) {
+	ls.mutex.RLock()
+	links := append([]string(nil), ls.links...)
+	ls.mutex.RUnlock()
+
+	ls.logf("🩺 Checking %d links...", len(links))
+
+	// Same client, but redirects are returned instead of followed
+	client := *ls.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	statuses := make([]LinkStatus, len(links))
+	checked := make([]bool, len(links))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < ls.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				statuses[index] = ls.checkLink(ctx, &client, links[index])
+				// A link whose check failed because of the interruption was not really checked
+				checked[index] = !statuses[index].Broken() || ctx.Err() == nil
+				if checked[index] && statuses[index].Broken() {
+					ls.logf("💔 Broken link: %s (%s)", links[index], statuses[index].describe())
+				}
+			}
+		}()
+	}
+
+feed:
+	for index := range links {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	stats := &LinkCheckStats{}
+	completed := make([]LinkStatus, 0, len(statuses))
+	for index, status := range statuses {
+		if !checked[index] {
+			stats.Partial = true
+			continue
+		}
+		completed = append(completed, status)
+		stats.Checked++
+		switch {
+		case status.Disallowed:
+			stats.Disallowed++
+		case status.Timeout:
+			stats.Timeouts++
+		case status.Error != "":
+			stats.Errors++
+		case status.StatusCode >= 500:
+			stats.Status5xx++
+		case status.StatusCode >= 400:
+			stats.Status4xx++
+		case status.StatusCode >= 300:
+			stats.Status3xx++
+		default:
+			stats.Status2xx++
+		}
+		if status.Broken() {
+			stats.Broken++
+		}
+	}
+
+	ls.mutex.Lock()
+	ls.linkStatuses = completed
+	ls.linkCheckStats = stats
+	ls.mutex.Unlock()
+
+	if stats.Partial {
+		ls.logf("⚠️  Link check interrupted: %d of %d links checked", stats.Checked, len(links))
+	}
+}
+
+func (ls *LinkScraper) checkLink(ctx context.Context, client *http.Client, link string) LinkStatus {
+	status := LinkStatus{URL: link}
+
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	allowed, err := ls.linkWait(ctx, parsedURL)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if !allowed {
+		status.Disallowed = true
+		return status
+	}
+
+	// Transient statuses (429, 503...) are retried too. Once the attempts
+	// run out, the last response is reported as is.
+	var resp *http.Response
+	var start time.Time
+	_, err = ls.withRetry(ctx, link, func() error {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		start = time.Now()
+		var err error
+		resp, err = ls.headOrGet(ctx, client, link)
+		if err != nil {
+			return err
+		}
+		if statusErr := statusError(resp); retryable(ctx, statusErr) {
+			return statusErr
+		}
+		return nil
+	})
+	status.ResponseTime = time.Since(start).Round(time.Millisecond).String()
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		err = nil
+	}
+
+	if err != nil {
+		var netErr net.Error
+		status.Timeout = errors.As(err, &netErr) && netErr.Timeout()
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	if location, err := resp.Location(); err == nil {
+		status.RedirectTo = location.String()
+	}
+	return status
+}
+
+// headOrGet tries a HEAD request, then GET when the server doesn't support HEAD
+func (ls *LinkScraper) headOrGet(ctx context.Context, client *http.Client, link string) (*http.Response, error) {
+	resp, err := ls.requestLink(ctx, client, http.MethodHead, link)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = ls.requestLink(ctx, client, http.MethodGet, link)
+	}
+	return resp, err
+}
+
+func (ls *LinkScraper) requestLink(ctx context.Context, client *http.Client, method, link string) (*http.Response, error) {
+	req, err := ls.newRequest(ctx, method, link)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		// Only the status matters, don't download the whole body
+		io.CopyN(io.Discard, resp.Body, 1024)
+	}
+	return resp, nil
+}
+
+func (s LinkStatus) describe() string {
+	if s.Disallowed {
+		return "disallowed by robots.txt"
+	}
+	if s.Timeout {
+		return "timeout"
+	}
+	if s.Error != "" {
+		return s.Error
+	}
+	return fmt.Sprintf("HTTP %d", s.StatusCode)
+}
+
+// brokenLinks returns the checked links that are broken
+func brokenLinks(statuses []LinkStatus) []LinkStatus {
+	broken := make([]LinkStatus, 0)
+	for _, status := range statuses {
+		if status.Broken() {
+			broken = append(broken, status)
+		}
+	}
+	return broken
+}
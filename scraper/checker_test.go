@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// pathRecorder is a test server noting the requested paths
+type pathRecorder struct {
+	*httptest.Server
+	mutex sync.Mutex
+	paths []string
+}
+
+func newPathRecorder(t *testing.T, handler http.HandlerFunc) *pathRecorder {
+	t.Helper()
+	recorder := &pathRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder.mutex.Lock()
+		recorder.paths = append(recorder.paths, r.URL.Path)
+		recorder.mutex.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+func (r *pathRecorder) requested(path string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Contains(r.paths, path)
+}
+
+// robotsSite serves a page linking to a disallowed internal page, an allowed
+// one and the same paths on external, which has no robots.txt
+func robotsSite(t *testing.T, external string) *pathRecorder {
+	t.Helper()
+	return newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			io.WriteString(w, "User-agent: *\nDisallow: /private")
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<a href="/public/page.html">Public</a>
+				<a href="/private/page.html">Private</a>
+				<a href="/public/file">Public file</a>
+				<a href="/private/file">Private file</a>
+				<a href="%[1]s/private/page.html">External</a>
+				<a href="%[1]s/private/file">External file</a>`, external)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<p>page</p>")
+		}
+	})
+}
+
+func TestLinkPassesFollowRobots(t *testing.T) {
+	external := newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "external")
+	})
+	site := robotsSite(t, external.URL)
+
+	ls, err := New(Config{BaseURL: site.URL + "/", MaxDepth: 0, Reporter: NewConsoleReporter(io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ls.Scrape(ctx)
+	ls.ClassifyByContentType(ctx)
+	ls.CheckLinks(ctx)
+
+	tests := []struct {
+		server *pathRecorder
+		path   string
+		want   bool
+	}{
+		{site, "/public/page.html", true},
+		{site, "/public/file", true},
+		{site, "/private/page.html", false},
+		{site, "/private/file", false},
+		{external, "/private/page.html", true},
+		{external, "/private/file", true},
+	}
+	for _, test := range tests {
+		if got := test.server.requested(test.path); got != test.want {
+			t.Errorf("%s%s requested: %v, want %v", test.server.URL, test.path, got, test.want)
+		}
+	}
+
+	results := ls.Results()
+	if check := results.Statistics.LinkCheck; check.Disallowed != 2 || check.Broken != 0 {
+		t.Errorf("link check: %d disallowed and %d broken, want 2 and 0", check.Disallowed, check.Broken)
+	}
+	for _, status := range results.CheckedLinks {
+		if status.URL == site.URL+"/private/page.html" && !status.Disallowed {
+			t.Errorf("status of %s = %+v, want disallowed", status.URL, status)
+		}
+	}
+	if contentTypes := results.Statistics.ContentTypes; contentTypes.Disallowed != 1 || contentTypes.Failed != 0 {
+		t.Errorf("Content-Type check: %d disallowed and %d failed, want 1 and 0", contentTypes.Disallowed, contentTypes.Failed)
+	}
+}
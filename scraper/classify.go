@@ -0,0 +1,200 @@
+package scraper
+
+import (
+	"mime"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LinkCategory is the kind of resource a link points to
+type LinkCategory string
+
+const (
+	CategoryHTML       LinkCategory = "html_pages"
+	CategoryDocument   LinkCategory = "documents"
+	CategoryImage      LinkCategory = "images"
+	CategoryScript     LinkCategory = "scripts"
+	CategoryStylesheet LinkCategory = "stylesheets"
+	CategoryMultimedia LinkCategory = "multimedia"
+	CategoryArchive    LinkCategory = "archives"
+	CategoryOther      LinkCategory = "other"
+)
+
+// ClassifiedLink is a link along with its category and file type
+type ClassifiedLink struct {
+	URL        string       `json:"url"`
+	Category   LinkCategory `json:"category"`
+	FileType   string       `json:"file_type"`
+	SourcePage string       `json:"source_page"` // page (or sitemap) where the link was first found
+	Depth      int          `json:"depth"`       // crawl depth of the source page
+	Element    string       `json:"element"`     // HTML element of the link: a, img, script, iframe... (or sitemap)
+	AnchorText string       `json:"anchor_text,omitempty"`
+	Rel        []string     `json:"rel,omitempty"`      // rel attribute values, e.g. nofollow, sponsored, ugc
+	Nofollow   bool         `json:"nofollow,omitempty"` // rel=nofollow, or a nofollow meta robots on the source page
+
+	// Set by ClassifyByContentType for the links it requested
+	ContentType     string       `json:"content_type,omitempty"`
+	GuessedCategory LinkCategory `json:"guessed_category,omitempty"` // category guessed from the extension, when Category comes from ContentType
+}
+
+// Categories lists every link category, in display order
+var Categories = []LinkCategory{
+	CategoryHTML,
+	CategoryDocument,
+	CategoryImage,
+	CategoryScript,
+	CategoryStylesheet,
+	CategoryMultimedia,
+	CategoryArchive,
+	CategoryOther,
+}
+
+// ParseCategory returns the category with the given name (e.g. "images")
+func ParseCategory(name string) (LinkCategory, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, category := range Categories {
+		if string(category) == name {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// Définition des extensions par catégorie
+var fileExtensions = map[LinkCategory][]string{
+	CategoryHTML:       {".html", ".htm", ".xhtml", ".php", ".asp", ".aspx", ".jsp", ".do"},
+	CategoryDocument:   {".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".txt", ".rtf", ".csv"},
+	CategoryImage:      {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".ico", ".tiff", ".tif"},
+	CategoryScript:     {".js", ".mjs", ".ts"},
+	CategoryStylesheet: {".css", ".scss", ".sass", ".less"},
+	CategoryMultimedia: {".mp4", ".avi", ".mov", ".wmv", ".flv", ".webm", ".mp3", ".wav", ".ogg", ".m4a", ".flac"},
+	CategoryArchive:    {".zip", ".rar", ".7z", ".tar", ".gz", ".bz2", ".xz"},
+}
+
+// ClassifyLink returns the category of a link and its file type, guessed from the extension
+func ClassifyLink(link string) (LinkCategory, string) {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return CategoryOther, "unknown"
+	}
+
+	path := strings.ToLower(parsedURL.Path)
+
+	// Si pas d'extension, vérifier si c'est probablement une page HTML
+	if !strings.Contains(path, ".") || strings.HasSuffix(path, "/") {
+		return CategoryHTML, "html"
+	}
+
+	// Extraire l'extension
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return CategoryHTML, "html"
+	}
+
+	category, _ := classifyExtension(ext)
+	return category, strings.TrimPrefix(ext, ".")
+}
+
+// classifyExtension returns the category of a file extension, and false when it is unknown
+func classifyExtension(ext string) (LinkCategory, bool) {
+	// Chercher dans nos catégories
+	for category, extensions := range fileExtensions {
+		for _, fileExt := range extensions {
+			if ext == fileExt {
+				return category, true
+			}
+		}
+	}
+	return CategoryOther, false
+}
+
+// Extensions of server-side pages, which may serve any kind of file (download.php?id=5)
+var dynamicExtensions = []string{".php", ".asp", ".aspx", ".jsp", ".do"}
+
+// ambiguousLink reports whether the category guessed by ClassifyLink is
+// doubtful: no extension, an unknown one, or a server-side page with a query
+func ambiguousLink(link string) bool {
+	parsedURL, err := url.Parse(link)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return false
+	}
+
+	path := strings.ToLower(parsedURL.Path)
+	ext := filepath.Ext(path)
+	if !strings.Contains(path, ".") || strings.HasSuffix(path, "/") || ext == "" {
+		return true
+	}
+	if _, known := classifyExtension(ext); !known {
+		return true
+	}
+	return parsedURL.RawQuery != "" && slices.Contains(dynamicExtensions, ext)
+}
+
+// Category and file type of the common content types. The other image/,
+// video/ and audio/ types are classified by their prefix.
+var contentTypes = map[string]struct {
+	category LinkCategory
+	fileType string
+}{
+	"text/html":                     {CategoryHTML, "html"},
+	"application/xhtml+xml":         {CategoryHTML, "xhtml"},
+	"application/pdf":               {CategoryDocument, "pdf"},
+	"application/msword":            {CategoryDocument, "doc"},
+	"application/vnd.ms-excel":      {CategoryDocument, "xls"},
+	"application/vnd.ms-powerpoint": {CategoryDocument, "ppt"},
+	"application/rtf":               {CategoryDocument, "rtf"},
+	"text/plain":                    {CategoryDocument, "txt"},
+	"text/csv":                      {CategoryDocument, "csv"},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {CategoryDocument, "docx"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {CategoryDocument, "xlsx"},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {CategoryDocument, "pptx"},
+	"application/vnd.oasis.opendocument.text":                                   {CategoryDocument, "odt"},
+	"application/vnd.oasis.opendocument.spreadsheet":                            {CategoryDocument, "ods"},
+	"application/vnd.oasis.opendocument.presentation":                           {CategoryDocument, "odp"},
+	"image/jpeg":                   {CategoryImage, "jpg"},
+	"image/svg+xml":                {CategoryImage, "svg"},
+	"image/x-icon":                 {CategoryImage, "ico"},
+	"image/vnd.microsoft.icon":     {CategoryImage, "ico"},
+	"text/javascript":              {CategoryScript, "js"},
+	"application/javascript":       {CategoryScript, "js"},
+	"application/x-javascript":     {CategoryScript, "js"},
+	"text/css":                     {CategoryStylesheet, "css"},
+	"audio/mpeg":                   {CategoryMultimedia, "mp3"},
+	"video/quicktime":              {CategoryMultimedia, "mov"},
+	"application/ogg":              {CategoryMultimedia, "ogg"},
+	"application/zip":              {CategoryArchive, "zip"},
+	"application/x-zip-compressed": {CategoryArchive, "zip"},
+	"application/vnd.rar":          {CategoryArchive, "rar"},
+	"application/x-rar-compressed": {CategoryArchive, "rar"},
+	"application/x-7z-compressed":  {CategoryArchive, "7z"},
+	"application/x-tar":            {CategoryArchive, "tar"},
+	"application/gzip":             {CategoryArchive, "gz"},
+	"application/x-gzip":           {CategoryArchive, "gz"},
+	"application/x-bzip2":          {CategoryArchive, "bz2"},
+	"application/x-xz":             {CategoryArchive, "xz"},
+}
+
+// ClassifyContentType returns the category and file type of a Content-Type
+// header value, and false when it doesn't tell (application/octet-stream...)
+func ClassifyContentType(contentType string) (LinkCategory, string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return CategoryOther, "unknown", false
+	}
+	if known, exists := contentTypes[mediaType]; exists {
+		return known.category, known.fileType, true
+	}
+
+	// image/png -> png, video/x-msvideo -> msvideo
+	kind, subtype, _ := strings.Cut(mediaType, "/")
+	subtype = strings.TrimPrefix(subtype, "x-")
+	switch kind {
+	case "image":
+		return CategoryImage, subtype, true
+	case "video", "audio":
+		return CategoryMultimedia, subtype, true
+	}
+	return CategoryOther, "unknown", false
+}
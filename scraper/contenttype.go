@@ -0,0 +1,239 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ContentTypeStats counts the links requested by ClassifyByContentType
+type ContentTypeStats struct {
+	Checked      int `json:"checked"`
+	Reclassified int `json:"reclassified"` // moved to another category
+	Unknown      int `json:"unknown"`      // Content-Type missing or not telling the category
+	Failed       int `json:"failed"`       // request failed or answered with an error status
+	Disallowed   int `json:"disallowed"`   // internal links not requested because of robots.txt
+}
+
+// contentTypeCache remembers the Content-Type of the URLs already requested.
+// It is shared by the scrapers of a Batch, and concurrent lookups of the same
+// URL make a single request.
+type contentTypeCache struct {
+	mutex   sync.Mutex
+	entries map[string]*contentTypeEntry
+}
+
+type contentTypeEntry struct {
+	done        chan struct{}
+	contentType string
+	filename    string // from Content-Disposition, for application/octet-stream downloads
+	err         error
+}
+
+func newContentTypeCache() *contentTypeCache {
+	return &contentTypeCache{entries: make(map[string]*contentTypeEntry)}
+}
+
+// lookup returns the cached entry of link, calling fetch when there is none.
+// Failed lookups are forgotten so that they can be tried again.
+func (c *contentTypeCache) lookup(link string, fetch func(entry *contentTypeEntry)) *contentTypeEntry {
+	c.mutex.Lock()
+	entry, exists := c.entries[link]
+	if !exists {
+		entry = &contentTypeEntry{done: make(chan struct{})}
+		c.entries[link] = entry
+	}
+	c.mutex.Unlock()
+
+	if exists {
+		<-entry.done
+		return entry
+	}
+
+	fetch(entry)
+	close(entry.done)
+	if entry.err != nil {
+		c.mutex.Lock()
+		delete(c.entries, link)
+		c.mutex.Unlock()
+	}
+	return entry
+}
+
+// store records the headers of a link fetched by other means, unless it was looked up already
+func (c *contentTypeCache) store(link, contentType, disposition string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, exists := c.entries[link]; exists {
+		return
+	}
+	entry := &contentTypeEntry{done: make(chan struct{})}
+	entry.setHeaders(contentType, disposition)
+	close(entry.done)
+	c.entries[link] = entry
+}
+
+func (e *contentTypeEntry) setHeaders(contentType, disposition string) {
+	e.contentType = contentType
+	if _, params, err := mime.ParseMediaType(disposition); err == nil {
+		e.filename = params["filename"]
+	}
+}
+
+// ClassifyByContentType requests the links whose category is doubtful (no
+// extension, an unknown one, or a server-side page with a query string like
+// download.php?id=5) and moves them to the category of their Content-Type.
+// A HEAD request is tried first, falling back to GET for servers that don't
+// support it; redirects are followed. The category guessed from the extension
+// is kept in GuessedCategory. Pages scraped during the crawl are known to be
+// HTML and are not requested again, nor are the documents the crawl tried
+// to scrape or the links verified before a resume.
+func (ls *LinkScraper) ClassifyByContentType(ctx context.Context) {
+	ls.mutex.RLock()
+	scraped := make(map[string]bool, len(ls.scrapedPages))
+	for _, link := range ls.scrapedPages {
+		scraped[link] = true
+	}
+	var links []string
+	for _, category := range Categories {
+		for _, link := range ls.classifiedLinks[category] {
+			if link.ContentType == "" && !scraped[link.URL] && ambiguousLink(link.URL) {
+				links = append(links, link.URL)
+			}
+		}
+	}
+	ls.mutex.RUnlock()
+
+	ls.logf("🔬 Checking the Content-Type of %d links...", len(links))
+
+	entries := make([]*contentTypeEntry, len(links))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < ls.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				entries[index] = ls.contentTypes.lookup(links[index], func(entry *contentTypeEntry) {
+					ls.fetchContentType(ctx, links[index], entry)
+				})
+			}
+		}()
+	}
+
+feed:
+	for index := range links {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Apply what was found, even when interrupted
+	verified := make(map[string]*contentTypeEntry, len(links))
+	stats := &ContentTypeStats{}
+	for index, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		stats.Checked++
+		if errors.Is(entry.err, errRobotsDisallowed) {
+			stats.Disallowed++
+			continue
+		}
+		if entry.err != nil {
+			stats.Failed++
+			continue
+		}
+		verified[links[index]] = entry
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	classifiedLinks := make(map[LinkCategory][]ClassifiedLink, len(ls.classifiedLinks))
+	for category, classified := range ls.classifiedLinks {
+		classifiedLinks[category] = make([]ClassifiedLink, 0, len(classified))
+	}
+	for _, category := range Categories {
+		for _, link := range ls.classifiedLinks[category] {
+			if entry, exists := verified[link.URL]; exists {
+				link.ContentType = entry.contentType
+				newCategory, fileType, ok := entry.classify()
+				switch {
+				case !ok:
+					stats.Unknown++
+				case newCategory != link.Category:
+					stats.Reclassified++
+					fallthrough
+				default:
+					link.GuessedCategory = link.Category
+					link.Category, link.FileType = newCategory, fileType
+				}
+			}
+			classifiedLinks[link.Category] = append(classifiedLinks[link.Category], link)
+		}
+	}
+	ls.classifiedLinks = classifiedLinks
+	ls.typeCheckStats = stats
+}
+
+// fetchContentType requests link and records its Content-Type in entry
+func (ls *LinkScraper) fetchContentType(ctx context.Context, link string, entry *contentTypeEntry) {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		entry.err = err
+		return
+	}
+	allowed, err := ls.linkWait(ctx, parsedURL)
+	if err != nil {
+		entry.err = err
+		return
+	}
+	if !allowed {
+		entry.err = errRobotsDisallowed
+		return
+	}
+
+	var resp *http.Response
+	_, entry.err = ls.withRetry(ctx, link, func() error {
+		var err error
+		resp, err = ls.headOrGet(ctx, ls.client, link)
+		if err != nil {
+			return err
+		}
+		if statusErr := statusError(resp); resp.StatusCode >= 400 {
+			resp.Body.Close()
+			return statusErr
+		}
+		return nil
+	})
+	if entry.err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	entry.setHeaders(resp.Header.Get("Content-Type"), resp.Header.Get("Content-Disposition"))
+}
+
+// classify returns the category of the fetched link, from its Content-Type or
+// else from the extension of the file name given by Content-Disposition
+func (e *contentTypeEntry) classify() (LinkCategory, string, bool) {
+	if category, fileType, ok := ClassifyContentType(e.contentType); ok {
+		return category, fileType, true
+	}
+	ext := strings.ToLower(filepath.Ext(e.filename))
+	if category, known := classifyExtension(ext); known {
+		return category, strings.TrimPrefix(ext, "."), true
+	}
+	return CategoryOther, "unknown", false
+}
@@ -0,0 +1,301 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DownloadOptions selects and limits the files fetched by DownloadAssets
+type DownloadOptions struct {
+	Categories   []LinkCategory
+	Workers      int   // concurrent downloads (default: Config.Workers)
+	MaxFileSize  int64 // in bytes, larger files are skipped (0 = unlimited)
+	MaxTotalSize int64 // in bytes, downloads stop once reached (0 = unlimited)
+}
+
+// DownloadedFile is an entry of the download manifest
+type DownloadedFile struct {
+	URL       string       `json:"url"`
+	Category  LinkCategory `json:"category"`
+	LocalPath string       `json:"local_path"` // relative to the session directory
+	Size      int64        `json:"size"`
+	SHA256    string       `json:"sha256"`
+}
+
+// DownloadStats sums up a download run
+type DownloadStats struct {
+	Downloaded int   `json:"downloaded"`
+	Skipped    int   `json:"skipped"`
+	Failed     int   `json:"failed"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// errSkipped is returned for files left out because of the size limits
+type errSkipped struct {
+	reason string
+}
+
+func (e errSkipped) Error() string {
+	return e.reason
+}
+
+// downloader holds the shared state of a download run
+type downloader struct {
+	ls         *LinkScraper
+	opts       DownloadOptions
+	sessionDir string
+	mutex      sync.Mutex
+	usedNames  map[string]bool
+	totalBytes int64
+}
+
+// DownloadAssets fetches the links of the selected categories into one
+// subfolder per category of the session directory, and writes the list of
+// downloaded files, with their size and SHA-256 checksum, to downloads.json.
+func (ls *LinkScraper) DownloadAssets(ctx context.Context, opts DownloadOptions) error {
+	sessionDir, err := ls.SessionDir()
+	if err != nil {
+		return err
+	}
+	return ls.downloadAssets(ctx, opts, sessionDir)
+}
+
+// downloadAssets downloads the files into sessionDir, see DownloadAssets
+func (ls *LinkScraper) downloadAssets(ctx context.Context, opts DownloadOptions, sessionDir string) error {
+	if opts.Workers < 1 {
+		opts.Workers = ls.workers
+	}
+
+	type job struct {
+		link     string
+		category LinkCategory
+	}
+	jobs := make([]job, 0)
+	ls.mutex.RLock()
+	for _, category := range opts.Categories {
+		for _, link := range ls.classifiedLinks[category] {
+			jobs = append(jobs, job{link: link.URL, category: category})
+		}
+	}
+	ls.mutex.RUnlock()
+
+	ls.logf("📥 Downloading %d files...", len(jobs))
+
+	d := &downloader{
+		ls:         ls,
+		opts:       opts,
+		sessionDir: sessionDir,
+		usedNames:  make(map[string]bool),
+	}
+	manifest := make([]DownloadedFile, 0)
+	stats := &DownloadStats{}
+
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	var resultMutex sync.Mutex
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				var file *DownloadedFile
+				_, err := ls.withRetry(ctx, j.link, func() error {
+					var err error
+					file, err = d.download(ctx, j.link, j.category)
+					return err
+				})
+
+				resultMutex.Lock()
+				switch err.(type) {
+				case nil:
+					manifest = append(manifest, *file)
+					stats.Downloaded++
+					stats.TotalBytes += file.Size
+				case errSkipped:
+					stats.Skipped++
+					ls.logf("⏭️  Skipped %s: %v", j.link, err)
+				default:
+					stats.Failed++
+					ls.addError(j.link, fmt.Sprintf("Error downloading %s: %v", j.link, err))
+				}
+				resultMutex.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	ls.mutex.Lock()
+	ls.downloadStats = stats
+	ls.mutex.Unlock()
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(sessionDir, "downloads.json"), manifestData, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing download manifest: %v", err)
+	}
+
+	ls.logf("📥 %d files downloaded (%d bytes), %d skipped, %d failed", stats.Downloaded, stats.TotalBytes, stats.Skipped, stats.Failed)
+	return nil
+}
+
+func (d *downloader) download(ctx context.Context, link string, category LinkCategory) (*DownloadedFile, error) {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := d.ls.politeWait(ctx, parsedURL)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errSkipped{reason: "disallowed by robots.txt"}
+	}
+	if d.totalLimitReached() {
+		return nil, errSkipped{reason: "total size limit reached"}
+	}
+
+	req, err := d.ls.newRequest(ctx, "GET", link)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.ls.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+	if d.opts.MaxFileSize > 0 && resp.ContentLength > d.opts.MaxFileSize {
+		return nil, errSkipped{reason: fmt.Sprintf("file too large (%d bytes)", resp.ContentLength)}
+	}
+
+	categoryDir := filepath.Join(d.sessionDir, string(category))
+	err = os.MkdirAll(categoryDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory: %v", err)
+	}
+
+	// Download into a temporary file, it is only kept once every limit is checked
+	tmpFile, err := os.CreateTemp(categoryDir, ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	var body io.Reader = resp.Body
+	if d.opts.MaxFileSize > 0 {
+		body = io.LimitReader(resp.Body, d.opts.MaxFileSize+1)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hash), body)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading: %v", err)
+	}
+	if d.opts.MaxFileSize > 0 && size > d.opts.MaxFileSize {
+		return nil, errSkipped{reason: fmt.Sprintf("file larger than %d bytes", d.opts.MaxFileSize)}
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("error writing file: %v", err)
+	}
+
+	// Reserve the size and a free file name together
+	d.mutex.Lock()
+	if d.opts.MaxTotalSize > 0 && d.totalBytes+size > d.opts.MaxTotalSize {
+		d.mutex.Unlock()
+		return nil, errSkipped{reason: "total size limit reached"}
+	}
+	d.totalBytes += size
+	localPath := d.reserveName(categoryDir, fileNameFromURL(parsedURL))
+	d.mutex.Unlock()
+
+	err = os.Rename(tmpFile.Name(), localPath)
+	if err != nil {
+		return nil, fmt.Errorf("error writing file: %v", err)
+	}
+
+	relativePath, _ := filepath.Rel(d.sessionDir, localPath)
+	return &DownloadedFile{
+		URL:       link,
+		Category:  category,
+		LocalPath: relativePath,
+		Size:      size,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// totalLimitReached reports whether the total size cap is already used up
+func (d *downloader) totalLimitReached() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.opts.MaxTotalSize > 0 && d.totalBytes >= d.opts.MaxTotalSize
+}
+
+// reserveName returns a path in dir that no other download uses, adding a
+// numeric suffix (photo_1.jpg, photo_2.jpg...) on collisions. Must be called with d.mutex held.
+func (d *downloader) reserveName(dir, name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	candidate := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if !d.usedNames[candidate] {
+			if _, err := os.Stat(candidate); os.IsNotExist(err) {
+				break
+			}
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+	}
+	d.usedNames[candidate] = true
+	return candidate
+}
+
+// fileNameFromURL derives a safe file name from the last segment of the URL path
+func fileNameFromURL(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "index"
+	}
+
+	// Keep file names portable
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '<', '>', ':', '"', '/', '\\', '|', '?', '*':
+			return '_'
+		}
+		if r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || strings.HasPrefix(name, ".") {
+		name = "file" + name
+	}
+	return name
+}
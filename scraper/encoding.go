@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// decodeContent undoes the Content-Encoding of a response body.
+// The returned function closes the decoder, not the body.
+func decodeContent(body io.Reader, contentEncoding string) (io.Reader, func(), error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		gzReader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating gzip reader: %v", err)
+		}
+		return gzReader, func() { gzReader.Close() }, nil
+	case "deflate":
+		flReader := flate.NewReader(body)
+		return flReader, func() { flReader.Close() }, nil
+	case "br":
+		return brotli.NewReader(body), func() {}, nil
+	case "", "identity":
+		return body, func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported content encoding: %s", contentEncoding)
+}
+
+// toUTF8 converts an HTML document to UTF-8. The charset comes from a BOM, the
+// Content-Type header or a <meta charset> tag, in this order (as browsers do).
+func toUTF8(html []byte, contentType string) ([]byte, error) {
+	enc, name, certain := charset.DetermineEncoding(html, contentType)
+	if enc == encoding.Nop {
+		return html, nil
+	}
+	// Without any declaration, windows-1252 is only a guess made on the
+	// beginning of the page: keep documents that are valid UTF-8 as they are
+	if !certain && name == "windows-1252" && utf8.Valid(html) {
+		return html, nil
+	}
+
+	converted, err := enc.NewDecoder().Bytes(html)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s content: %v", name, err)
+	}
+	return converted, nil
+}
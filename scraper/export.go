@@ -0,0 +1,204 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OutputFormat is a file format written by SaveResults
+type OutputFormat string
+
+const (
+	// FormatJSON writes summary.json and one <category>.json file per category
+	FormatJSON OutputFormat = "json"
+	// FormatCSV writes links.csv, one row per classified link
+	FormatCSV OutputFormat = "csv"
+	// FormatNDJSON writes links.ndjson, one JSON object per line and link
+	FormatNDJSON OutputFormat = "ndjson"
+	// FormatText writes one <category>.txt file per category, one URL per line
+	FormatText OutputFormat = "txt"
+)
+
+// OutputFormats lists every supported output format
+var OutputFormats = []OutputFormat{FormatJSON, FormatCSV, FormatNDJSON, FormatText}
+
+func (f OutputFormat) valid() bool {
+	for _, format := range OutputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// linkRecord is the flat form of a classified link used by the CSV and NDJSON exports
+type linkRecord struct {
+	URL        string       `json:"url"`
+	Category   LinkCategory `json:"category"`
+	FileType   string       `json:"file_type"`
+	Scope      string       `json:"scope"` // internal or external
+	Depth      int          `json:"depth"`
+	SourcePage string       `json:"source_page"`
+	Element    string       `json:"element"`
+	AnchorText string       `json:"anchor_text"`
+	Rel        string       `json:"rel"` // space-separated, as in HTML
+	Nofollow   bool         `json:"nofollow"`
+
+	ContentType     string       `json:"content_type,omitempty"`
+	GuessedCategory LinkCategory `json:"guessed_category,omitempty"`
+}
+
+func writeFormat(sessionDir string, format OutputFormat, results ScrapingResults) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(sessionDir, results)
+	case FormatCSV:
+		return writeCSV(filepath.Join(sessionDir, "links.csv"), results)
+	case FormatNDJSON:
+		return writeNDJSON(filepath.Join(sessionDir, "links.ndjson"), results)
+	case FormatText:
+		return writeText(sessionDir, results)
+	}
+	return fmt.Errorf("unknown output format: %q", format)
+}
+
+func writeJSON(sessionDir string, results ScrapingResults) error {
+	// Sauvegarder le résumé principal
+	mainFile := filepath.Join(sessionDir, "summary.json")
+	jsonData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	err = os.WriteFile(mainFile, jsonData, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing summary file: %v", err)
+	}
+
+	// Sauvegarder chaque catégorie dans un fichier séparé
+	for category, links := range results.ClassifiedLinks {
+		if len(links) > 0 {
+			categoryFile := filepath.Join(sessionDir, fmt.Sprintf("%s.json", category))
+			categoryData, err := json.MarshalIndent(links, "", "  ")
+			if err != nil {
+				continue
+			}
+			os.WriteFile(categoryFile, categoryData, 0644)
+		}
+	}
+	return nil
+}
+
+func writeCSV(path string, results ScrapingResults) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating CSV file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"url", "category", "file_type", "scope", "depth", "source_page", "element", "anchor_text", "rel", "nofollow", "content_type", "guessed_category"})
+	for _, record := range linkRecords(results) {
+		writer.Write([]string{
+			record.URL,
+			string(record.Category),
+			record.FileType,
+			record.Scope,
+			strconv.Itoa(record.Depth),
+			record.SourcePage,
+			record.Element,
+			record.AnchorText,
+			record.Rel,
+			strconv.FormatBool(record.Nofollow),
+			record.ContentType,
+			string(record.GuessedCategory),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing CSV file: %v", err)
+	}
+	return file.Close()
+}
+
+func writeNDJSON(path string, results ScrapingResults) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating NDJSON file: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range linkRecords(results) {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error writing NDJSON file: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing NDJSON file: %v", err)
+	}
+	return file.Close()
+}
+
+func writeText(sessionDir string, results ScrapingResults) error {
+	for category, links := range results.ClassifiedLinks {
+		if len(links) == 0 {
+			continue
+		}
+
+		file, err := os.Create(filepath.Join(sessionDir, fmt.Sprintf("%s.txt", category)))
+		if err != nil {
+			return fmt.Errorf("error creating text file: %v", err)
+		}
+		writer := bufio.NewWriter(file)
+		for _, link := range links {
+			fmt.Fprintln(writer, link.URL)
+		}
+		err = writer.Flush()
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("error writing text file: %v", err)
+		}
+	}
+	return nil
+}
+
+// linkRecords flattens the classified links, category by category
+func linkRecords(results ScrapingResults) []linkRecord {
+	internal := make(map[string]bool, len(results.InternalLinks))
+	for _, link := range results.InternalLinks {
+		internal[link] = true
+	}
+
+	records := make([]linkRecord, 0, results.TotalLinks)
+	for _, category := range Categories {
+		for _, link := range results.ClassifiedLinks[category] {
+			scope := "external"
+			if internal[link.URL] {
+				scope = "internal"
+			}
+			records = append(records, linkRecord{
+				URL:        link.URL,
+				Category:   link.Category,
+				FileType:   link.FileType,
+				Scope:      scope,
+				Depth:      link.Depth,
+				SourcePage: link.SourcePage,
+				Element:    link.Element,
+				AnchorText: link.AnchorText,
+				Rel:        strings.Join(link.Rel, " "),
+				Nofollow:   link.Nofollow,
+
+				ContentType:     link.ContentType,
+				GuessedCategory: link.GuessedCategory,
+			})
+		}
+	}
+	return records
+}
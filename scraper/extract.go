@@ -0,0 +1,222 @@
+package scraper
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseSrcset returns the URLs of the candidates of a srcset attribute
+// ("small.jpg 480w, large.jpg 1080w"), following the HTML parsing rules:
+// URLs may contain commas, and descriptors may contain parenthesized commas.
+func parseSrcset(srcset string) []string {
+	var urls []string
+	pos := 0
+	for {
+		// Skip the separators before the candidate
+		for pos < len(srcset) && (isHTMLSpace(srcset[pos]) || srcset[pos] == ',') {
+			pos++
+		}
+		if pos >= len(srcset) {
+			return urls
+		}
+
+		start := pos
+		for pos < len(srcset) && !isHTMLSpace(srcset[pos]) {
+			pos++
+		}
+		candidate := srcset[start:pos]
+
+		if strings.HasSuffix(candidate, ",") {
+			// "a.jpg, b.jpg 2x": a comma ends the URL, the candidate has no descriptors
+			candidate = strings.TrimRight(candidate, ",")
+		} else {
+			// Skip the descriptors ("2x", "480w"), up to the next comma
+			inParens := false
+			for ; pos < len(srcset); pos++ {
+				c := srcset[pos]
+				if c == '(' {
+					inParens = true
+				} else if c == ')' {
+					inParens = false
+				} else if c == ',' && !inParens {
+					pos++
+					break
+				}
+			}
+		}
+		if candidate != "" {
+			urls = append(urls, candidate)
+		}
+	}
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// cssURLs returns the URLs referenced by a style sheet or a style attribute:
+// url(...) values and the strings of @import rules. Comments are skipped and
+// CSS escapes decoded.
+func cssURLs(css string) []string {
+	var urls []string
+	for i := 0; i < len(css); {
+		switch {
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return urls
+			}
+			i += end + 4
+		case css[i] == '"' || css[i] == '\'':
+			// Strings are skipped whole, "url(" inside them is not a reference
+			_, i = cssString(css, i)
+		case css[i] == '\\':
+			_, i = cssEscape(css, i+1)
+		case hasPrefixFold(css[i:], "url(") && (i == 0 || !isCSSNameByte(css[i-1])):
+			var value string
+			value, i = cssURL(css, i+len("url("))
+			if value != "" {
+				urls = append(urls, value)
+			}
+		case hasPrefixFold(css[i:], "@import"):
+			i += len("@import")
+			for i < len(css) && isHTMLSpace(css[i]) {
+				i++
+			}
+			if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+				var value string
+				value, i = cssString(css, i)
+				if value != "" {
+					urls = append(urls, value)
+				}
+			}
+		default:
+			i++
+		}
+	}
+	return urls
+}
+
+// cssURL reads the value of a url( token, from start (just after the
+// parenthesis), and returns it along with the position after the closing one
+func cssURL(css string, start int) (string, int) {
+	i := start
+	for i < len(css) && isHTMLSpace(css[i]) {
+		i++
+	}
+
+	var value strings.Builder
+	if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+		var quoted string
+		quoted, i = cssString(css, i)
+		value.WriteString(quoted)
+	} else {
+		for i < len(css) && css[i] != ')' && !isHTMLSpace(css[i]) {
+			if css[i] == '\\' {
+				var r rune
+				r, i = cssEscape(css, i+1)
+				value.WriteRune(r)
+				continue
+			}
+			value.WriteByte(css[i])
+			i++
+		}
+	}
+
+	// Skip the trailing whitespace and the closing parenthesis
+	for i < len(css) && css[i] != ')' {
+		i++
+	}
+	return strings.TrimSpace(value.String()), min(i+1, len(css))
+}
+
+// cssString reads the quoted string starting at start and returns its value
+// along with the position after the closing quote
+func cssString(css string, start int) (string, int) {
+	quote := css[start]
+	var value strings.Builder
+	i := start + 1
+	for i < len(css) {
+		switch c := css[i]; {
+		case c == quote:
+			return value.String(), i + 1
+		case c == '\n':
+			// Unterminated string
+			return value.String(), i
+		case c == '\\' && i+1 < len(css) && css[i+1] == '\n':
+			// Line continuation
+			i += 2
+		case c == '\\':
+			var r rune
+			r, i = cssEscape(css, i+1)
+			value.WriteRune(r)
+		default:
+			value.WriteByte(c)
+			i++
+		}
+	}
+	return value.String(), i
+}
+
+// cssEscape decodes the escape sequence starting at start (just after the
+// backslash): up to 6 hex digits and an optional space, or any other character
+func cssEscape(css string, start int) (rune, int) {
+	if start >= len(css) {
+		return utf8.RuneError, start
+	}
+	end := start
+	for end < len(css) && end-start < 6 && isHexByte(css[end]) {
+		end++
+	}
+	if end == start {
+		r, size := utf8.DecodeRuneInString(css[start:])
+		return r, start + size
+	}
+
+	code, _ := strconv.ParseUint(css[start:end], 16, 32)
+	if end < len(css) && isHTMLSpace(css[end]) {
+		end++
+	}
+	if code == 0 || code > utf8.MaxRune || (code >= 0xD800 && code <= 0xDFFF) {
+		return utf8.RuneError, end
+	}
+	return rune(code), end
+}
+
+func isHexByte(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isCSSNameByte(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c >= 0x80
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// metaRefreshURL returns the target of a <meta http-equiv="refresh"> content
+// ("5; url=/next", "0;URL='/next'", "0, /next"), or "" when it only reloads the page
+func metaRefreshURL(content string) string {
+	rest := strings.TrimLeft(content, " \t\n\r\f")
+	rest = strings.TrimLeft(rest, "0123456789.")
+	rest = strings.TrimLeft(rest, " \t\n\r\f")
+	if rest != "" && (rest[0] == ';' || rest[0] == ',') {
+		rest = strings.TrimLeft(rest[1:], " \t\n\r\f")
+	}
+
+	if hasPrefixFold(rest, "url") {
+		if value := strings.TrimLeft(rest[len("url"):], " \t\n\r\f"); strings.HasPrefix(value, "=") {
+			rest = strings.TrimLeft(value[1:], " \t\n\r\f")
+		}
+	}
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+		quote := rest[0]
+		rest = rest[1:]
+		if end := strings.IndexByte(rest, quote); end >= 0 {
+			rest = rest[:end]
+		}
+	}
+	return strings.TrimSpace(rest)
+}
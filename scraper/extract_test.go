@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []string
+	}{
+		{"small.jpg 480w, large.jpg 1080w", []string{"small.jpg", "large.jpg"}},
+		{"a.jpg", []string{"a.jpg"}},
+		{"a.jpg, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg,b.jpg 2x", []string{"a.jpg,b.jpg"}},
+		{"a.jpg 1x,b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"/img?size=1,2 1x, /img?size=3,4 2x", []string{"/img?size=1,2", "/img?size=3,4"}},
+		{"a.jpg (foo, bar) 1x, b.jpg", []string{"a.jpg", "b.jpg"}},
+		{"  \n a.jpg  ,  , b.jpg ", []string{"a.jpg", "b.jpg"}},
+		{"", nil},
+		{" , ,", nil},
+	}
+	for _, test := range tests {
+		if got := parseSrcset(test.srcset); !slices.Equal(got, test.want) {
+			t.Errorf("parseSrcset(%q) = %q, want %q", test.srcset, got, test.want)
+		}
+	}
+}
+
+func TestCSSURLs(t *testing.T) {
+	tests := []struct {
+		css  string
+		want []string
+	}{
+		{`body { background: url(bg.png) }`, []string{"bg.png"}},
+		{`a { background: url( "a\"b" ) }`, []string{`a"b`}},
+		{`a { background: url('single.png') }`, []string{"single.png"}},
+		{`a { background: URL(  spaced.png  ) }`, []string{"spaced.png"}},
+		{`a { background: url(\66 oo.png) }`, []string{"foo.png"}},
+		{`a { background: url(a\(b\).png) }`, []string{"a(b).png"}},
+		{`a { background: url("\e9t\E9.png") }`, []string{"été.png"}},
+		{`/* url(commented.png) */ a { background: url(kept.png) }`, []string{"kept.png"}},
+		{`a { content: "url(in-string.png)"; background: url(real.png) }`, []string{"real.png"}},
+		{`a { mask: myurl(no.png) }`, nil},
+		{`@import "base.css"; @import url(print.css) print;`, []string{"base.css", "print.css"}},
+		{`@IMPORT 'upper.css';`, []string{"upper.css"}},
+		{`a { background: url() }`, nil},
+		{`a { background: url(unterminated.png`, []string{"unterminated.png"}},
+		{`/* unterminated comment url(no.png)`, nil},
+	}
+	for _, test := range tests {
+		if got := cssURLs(test.css); !slices.Equal(got, test.want) {
+			t.Errorf("cssURLs(%q) = %q, want %q", test.css, got, test.want)
+		}
+	}
+}
+
+func TestMetaRefreshURL(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"5", ""},
+		{"0", ""},
+		{"", ""},
+		{"5; url=/next", "/next"},
+		{"5;url=/next", "/next"},
+		{"0; URL=/next", "/next"},
+		{"0;URL='/quoted'", "/quoted"},
+		{`0; url="/double quoted"`, "/double quoted"},
+		{"0; Url = /spaced ", "/spaced"},
+		{"0, /comma", "/comma"},
+		{"1.5; url=/decimal", "/decimal"},
+		{"0; /no-url-prefix", "/no-url-prefix"},
+		{"0; url='/unterminated", "/unterminated"},
+	}
+	for _, test := range tests {
+		if got := metaRefreshURL(test.content); got != test.want {
+			t.Errorf("metaRefreshURL(%q) = %q, want %q", test.content, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Page is an HTML document loaded by a Fetcher
+type Page struct {
+	URL       string     // URL of the document, after redirects
+	Redirects []Redirect // redirects followed to reach URL, when known
+	HTML      []byte
+}
+
+// Fetcher loads the pages of the crawl. The links are then extracted from
+// the returned HTML, whatever the way it was obtained.
+// Implementations must be safe for concurrent use by the crawl workers and
+// return an error for failed requests and non-HTML documents.
+type Fetcher interface {
+	Fetch(ctx context.Context, link string) (*Page, error)
+}
+
+// httpFetcher is the default Fetcher: a plain GET request with browser-like headers.
+// Redirects are followed by hand so that the chain can be recorded.
+type httpFetcher struct {
+	client      *http.Client
+	headers     map[string]string
+	headerHosts hostScope // hosts the headers are sent to
+
+	// beforeRedirect is called before following each redirect, like before
+	// the first request: it checks robots.txt and waits for the rate limiter
+	beforeRedirect func(ctx context.Context, u *url.URL) (bool, error)
+}
+
+func newHTTPFetcher(client *http.Client, headers map[string]string, headerHosts hostScope) *httpFetcher {
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &httpFetcher{client: &noRedirect, headers: headers, headerHosts: headerHosts}
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, link string) (*Page, error) {
+	var redirects []Redirect
+	seen := make(map[string]bool)
+	current := link
+	for {
+		if seen[current] {
+			chain := make([]string, 0, len(redirects)+1)
+			for _, redirect := range redirects {
+				chain = append(chain, redirect.URL)
+			}
+			return nil, errRedirectLoop{chain: append(chain, current)}
+		}
+		seen[current] = true
+
+		if len(redirects) > 0 && f.beforeRedirect != nil {
+			target, err := url.Parse(current)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redirect to %s: %v", current, err)
+			}
+			allowed, err := f.beforeRedirect(ctx, target)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				return nil, errDisallowedRedirect{url: current}
+			}
+		}
+
+		resp, err := f.get(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if !isRedirect(resp.StatusCode) {
+			defer resp.Body.Close()
+			html, err := readHTML(resp)
+			if err != nil {
+				return nil, err
+			}
+			return &Page{URL: current, Redirects: redirects, HTML: html}, nil
+		}
+
+		location, err := resp.Location()
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect from %s: %v", current, err)
+		}
+		redirects = append(redirects, Redirect{URL: current, StatusCode: resp.StatusCode})
+		if len(redirects) > maxRedirects {
+			return nil, fmt.Errorf("more than %d redirects", maxRedirects)
+		}
+		current = location.String()
+	}
+}
+
+func (f *httpFetcher) get(ctx context.Context, link string) (*http.Response, error) {
+	// Create request with realistic headers
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	// Realistic headers to avoid blocking
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,fr;q=0.8")
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.Header.Set("Cache-Control", "max-age=0")
+
+	// Headers from the configuration come last so they can override the defaults
+	f.headerHosts.setHeaders(req, f.headers)
+
+	// Make HTTP request
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	return resp, nil
+}
+
+// errNonHTML is returned for documents that are not HTML pages
+type errNonHTML struct {
+	contentType string
+	disposition string // Content-Disposition header, naming the file of downloads
+}
+
+func (e errNonHTML) Error() string {
+	return "non-HTML content detected: " + e.contentType
+}
+
+// readHTML checks the response and returns its decoded HTML body
+func readHTML(resp *http.Response) ([]byte, error) {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError(resp)
+	}
+
+	// Check Content-Type
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(strings.ToLower(contentType), "text/html") {
+		return nil, errNonHTML{contentType: contentType, disposition: resp.Header.Get("Content-Disposition")}
+	}
+
+	reader, closeReader, err := decodeContent(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+
+	html, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	return toUTF8(html, contentType)
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FilterStats counts the URLs rejected by the include/exclude filters.
+// Each URL is counted once, for the first rule it failed.
+type FilterStats struct {
+	Excluded    int `json:"excluded"`     // matched an exclude pattern
+	NotIncluded int `json:"not_included"` // matched none of the include patterns
+	OutOfScope  int `json:"out_of_scope"` // outside every path prefix
+}
+
+// urlFilter decides which URLs are followed, and optionally recorded
+type urlFilter struct {
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	prefixes []string
+}
+
+type filterVerdict int
+
+const (
+	filterAllowed filterVerdict = iota
+	filterExcluded
+	filterNotIncluded
+	filterOutOfScope
+)
+
+func newURLFilter(include, exclude, prefixes []string) (*urlFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 && len(prefixes) == 0 {
+		return nil, nil
+	}
+
+	f := &urlFilter{prefixes: prefixes}
+	for _, pattern := range include {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %v", pattern, err)
+		}
+		f.include = append(f.include, regex)
+	}
+	for _, pattern := range exclude {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+		f.exclude = append(f.exclude, regex)
+	}
+	return f, nil
+}
+
+// check applies the rules in order: path prefixes, exclude patterns (which
+// win over include patterns), then include patterns. Patterns are matched
+// against the full URL, prefixes against its path.
+func (f *urlFilter) check(link string) filterVerdict {
+	if f == nil {
+		return filterAllowed
+	}
+
+	if len(f.prefixes) > 0 {
+		parsedURL, err := url.Parse(link)
+		if err != nil {
+			return filterOutOfScope
+		}
+		inScope := false
+		for _, prefix := range f.prefixes {
+			if strings.HasPrefix(parsedURL.Path, prefix) {
+				inScope = true
+				break
+			}
+		}
+		if !inScope {
+			return filterOutOfScope
+		}
+	}
+
+	for _, regex := range f.exclude {
+		if regex.MatchString(link) {
+			return filterExcluded
+		}
+	}
+
+	if len(f.include) == 0 {
+		return filterAllowed
+	}
+	for _, regex := range f.include {
+		if regex.MatchString(link) {
+			return filterAllowed
+		}
+	}
+	return filterNotIncluded
+}
+
+// passesFilters reports whether the link is accepted by the URL filters and
+// counts the rejections. Must be called with ls.mutex held.
+func (ls *LinkScraper) passesFilters(link string) bool {
+	verdict := ls.filter.check(link)
+	if verdict == filterAllowed {
+		return true
+	}
+
+	if !ls.filteredURLs[link] {
+		ls.filteredURLs[link] = true
+		switch verdict {
+		case filterExcluded:
+			ls.filterStats.Excluded++
+		case filterNotIncluded:
+			ls.filterStats.NotIncluded++
+		case filterOutOfScope:
+			ls.filterStats.OutOfScope++
+		}
+	}
+	return false
+}
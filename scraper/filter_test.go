@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestURLFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		prefixes []string
+		link     string
+		want     filterVerdict
+	}{
+		{"no rule", nil, nil, nil, "https://example.com/any", filterAllowed},
+		{"included", []string{`/docs/`}, nil, nil, "https://example.com/docs/a", filterAllowed},
+		{"not included", []string{`/docs/`}, nil, nil, "https://example.com/blog/a", filterNotIncluded},
+		{"one of the includes", []string{`/docs/`, `/blog/`}, nil, nil, "https://example.com/blog/a", filterAllowed},
+		{"excluded", nil, []string{`\?page=`}, nil, "https://example.com/list?page=2", filterExcluded},
+		{"exclude wins", []string{`/docs/`}, []string{`/archive/`}, nil, "https://example.com/docs/archive/a", filterExcluded},
+		{"pattern on the full URL", []string{`^https://example\.com/`}, nil, nil, "https://other.example/docs/", filterNotIncluded},
+		{"in a prefix", nil, nil, []string{"/docs/", "/api/"}, "https://example.com/api/v1", filterAllowed},
+		{"out of scope", nil, nil, []string{"/docs/"}, "https://example.com/blog/docs/", filterOutOfScope},
+		{"prefix on the path only", nil, nil, []string{"/docs/"}, "https://example.com/?next=/docs/", filterOutOfScope},
+		{"prefix first", nil, []string{`/blog/`}, []string{"/docs/"}, "https://example.com/blog/", filterOutOfScope},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newURLFilter(test.include, test.exclude, test.prefixes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.check(test.link); got != test.want {
+				t.Errorf("check(%q) = %v, want %v", test.link, got, test.want)
+			}
+		})
+	}
+}
+
+func TestURLFilterInvalidPattern(t *testing.T) {
+	if _, err := newURLFilter([]string{"("}, nil, nil); err == nil || !strings.Contains(err.Error(), "include") {
+		t.Errorf("invalid include pattern: error %v", err)
+	}
+	if _, err := newURLFilter(nil, []string{"["}, nil); err == nil || !strings.Contains(err.Error(), "exclude") {
+		t.Errorf("invalid exclude pattern: error %v", err)
+	}
+}
+
+// filterSite serves a documentation section, a blog, an excluded archive and
+// a page outside the prefixes, all linked twice from the home page
+func filterSite(t *testing.T) *pathRecorder {
+	t.Helper()
+	return newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/docs/":
+			links := `<a href="/docs/guide.html">Guide</a><a href="/docs/archive/old.html">Old</a>
+				<a href="/blog/post.html">Post</a><a href="/about.html">About</a>`
+			io.WriteString(w, links+links)
+		case "/docs/guide.html", "/docs/archive/old.html", "/blog/post.html", "/about.html":
+			io.WriteString(w, `<a href="/docs/">Home</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestFiltersOnCrawl(t *testing.T) {
+	tests := []struct {
+		name      string
+		recorded  bool
+		wantLinks []string
+	}{
+		{"filters the followed pages", false, []string{"/about.html", "/blog/post.html", "/docs/", "/docs/archive/old.html", "/docs/guide.html"}},
+		{"filters the recorded links", true, []string{"/docs/", "/docs/guide.html"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := filterSite(t)
+			ls, err := New(Config{
+				BaseURL:         site.URL + "/docs/",
+				MaxDepth:        2,
+				IncludePatterns: []string{`/docs/`, `/about\.html$`},
+				ExcludePatterns: []string{`/archive/`},
+				PathPrefixes:    []string{"/docs/", "/blog/"},
+				FilterRecorded:  test.recorded,
+				Reporter:        NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ls.Scrape(context.Background())
+			results := ls.Results()
+
+			if want := []string{"/docs/", "/docs/guide.html"}; !slices.Equal(site.pages(), want) {
+				t.Errorf("requested pages = %q, want %q", site.pages(), want)
+			}
+			var links []string
+			for _, link := range results.AllLinks {
+				links = append(links, strings.TrimPrefix(link, site.URL))
+			}
+			slices.Sort(links)
+			if !slices.Equal(links, test.wantLinks) {
+				t.Errorf("links = %q, want %q", links, test.wantLinks)
+			}
+			// Each filtered URL is counted once, however often it is found
+			want := FilterStats{Excluded: 1, NotIncluded: 1, OutOfScope: 1}
+			if got := results.Statistics.Filters; got == nil || *got != want {
+				t.Errorf("filter stats = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// The base URL is crawled even when the filters reject it
+func TestFiltersKeepTheBaseURL(t *testing.T) {
+	site := filterSite(t)
+	ls, err := New(Config{
+		BaseURL:         site.URL + "/docs/",
+		MaxDepth:        1,
+		ExcludePatterns: []string{`/docs/$`},
+		Reporter:        NewConsoleReporter(io.Discard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls.Scrape(context.Background())
+	if !site.requested("/docs/") || !site.requested("/docs/guide.html") {
+		t.Errorf("requested pages = %q, want the base URL and its links", site.pages())
+	}
+}
@@ -0,0 +1,105 @@
+package scraper
+
+import "sync"
+
+// CrawlTask is a page waiting to be scraped, with the depth at which it was found
+type CrawlTask struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// frontier is the FIFO queue shared by the crawl workers.
+// It keeps track of queued and in-flight tasks so that workers know
+// when the crawl is over: the queue is empty and nobody is still working.
+type frontier struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	queue    []CrawlTask
+	inflight map[CrawlTask]int
+	pending  int
+	closed   bool
+}
+
+func newFrontier() *frontier {
+	f := &frontier{
+		queue:    make([]CrawlTask, 0),
+		inflight: make(map[CrawlTask]int),
+	}
+	f.cond = sync.NewCond(&f.mutex)
+	return f
+}
+
+// push adds a task at the end of the queue.
+// Tasks pushed after close are kept so that they end up in the next snapshot.
+func (f *frontier) push(task CrawlTask) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.queue = append(f.queue, task)
+	f.pending++
+	f.cond.Signal()
+}
+
+// pop blocks until a task is available. It returns false once the frontier
+// is closed or drained (empty queue and no task in flight).
+func (f *frontier) pop() (CrawlTask, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for len(f.queue) == 0 && !f.closed {
+		if f.pending == 0 {
+			return CrawlTask{}, false
+		}
+		f.cond.Wait()
+	}
+	if f.closed {
+		return CrawlTask{}, false
+	}
+
+	task := f.queue[0]
+	f.queue = f.queue[1:]
+	f.inflight[task]++
+	return task, true
+}
+
+// done marks a popped task as finished
+func (f *frontier) done(task CrawlTask) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.inflight[task]--
+	if f.inflight[task] <= 0 {
+		delete(f.inflight, task)
+	}
+	f.pending--
+	if f.pending == 0 {
+		f.cond.Broadcast()
+	}
+}
+
+// close stops the frontier and wakes up every waiting worker
+func (f *frontier) close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+// size returns the number of tasks still waiting in the queue
+func (f *frontier) size() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.queue)
+}
+
+// tasksLocked returns every task that still has to be scraped: the in-flight
+// ones first, as they were not finished, then the queued ones. The caller
+// holds f.mutex, so that the tasks can be captured along with other state.
+func (f *frontier) tasksLocked() []CrawlTask {
+	tasks := make([]CrawlTask, 0, len(f.inflight)+len(f.queue))
+	for task := range f.inflight {
+		tasks = append(tasks, task)
+	}
+	return append(tasks, f.queue...)
+}
@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// popAsync pops from f in a goroutine, so that a blocking pop can be observed
+func popAsync(f *frontier) <-chan bool {
+	result := make(chan bool, 1)
+	go func() {
+		_, ok := f.pop()
+		result <- ok
+	}()
+	return result
+}
+
+func expectBlocked(t *testing.T, result <-chan bool) {
+	t.Helper()
+	select {
+	case ok := <-result:
+		t.Fatalf("pop returned %v while a task was in flight", ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func expectPopped(t *testing.T, result <-chan bool, want bool) {
+	t.Helper()
+	select {
+	case ok := <-result:
+		if ok != want {
+			t.Fatalf("pop returned %v, want %v", ok, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pop still blocked")
+	}
+}
+
+func TestFrontierDrain(t *testing.T) {
+	f := newFrontier()
+	home := CrawlTask{URL: "https://example.com/"}
+	f.push(home)
+	if task, ok := f.pop(); !ok || task != home {
+		t.Fatalf("pop = %v, %v, want the home page", task, ok)
+	}
+
+	// The queue is empty but the home page may still find links
+	waiting := popAsync(f)
+	expectBlocked(t, waiting)
+	child := CrawlTask{URL: "https://example.com/child", Depth: 1}
+	f.push(child)
+	expectPopped(t, waiting, true)
+
+	f.done(home)
+	waiting = popAsync(f)
+	expectBlocked(t, waiting)
+	f.done(child)
+	expectPopped(t, waiting, false)
+
+	if _, ok := f.pop(); ok {
+		t.Error("pop succeeded on a drained frontier")
+	}
+}
+
+func TestFrontierClose(t *testing.T) {
+	f := newFrontier()
+	inflight := CrawlTask{URL: "https://example.com/"}
+	f.push(inflight)
+	f.pop()
+
+	waiting := popAsync(f)
+	expectBlocked(t, waiting)
+	f.close()
+	expectPopped(t, waiting, false)
+
+	// Tasks pushed once closed are not handed out but are kept for the state
+	late := CrawlTask{URL: "https://example.com/late", Depth: 1}
+	f.push(late)
+	if _, ok := f.pop(); ok {
+		t.Error("pop succeeded on a closed frontier")
+	}
+	f.mutex.Lock()
+	tasks := f.tasksLocked()
+	f.mutex.Unlock()
+	if want := []CrawlTask{inflight, late}; !slices.Equal(tasks, want) {
+		t.Errorf("tasks = %v, want %v", tasks, want)
+	}
+}
+
+func TestFrontierInflight(t *testing.T) {
+	f := newFrontier()
+	task := CrawlTask{URL: "https://example.com/"}
+	queued := CrawlTask{URL: "https://example.com/queued"}
+	// The same task may be queued twice, e.g. when a resumed state holds it
+	f.push(task)
+	f.push(task)
+	f.pop()
+	f.pop()
+	f.push(queued)
+
+	f.done(task)
+	f.mutex.Lock()
+	tasks, pending := f.tasksLocked(), f.pending
+	f.mutex.Unlock()
+	if want := []CrawlTask{task, queued}; !slices.Equal(tasks, want) {
+		t.Errorf("tasks = %v with one of the two copies done, want %v", tasks, want)
+	}
+	if pending != 2 {
+		t.Errorf("pending = %d, want 2", pending)
+	}
+	if size := f.size(); size != 1 {
+		t.Errorf("size = %d, want the queued task only", size)
+	}
+}
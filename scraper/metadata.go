@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Longest anchor text kept, in characters
+const maxAnchorTextLength = 200
+
+// linkOrigin describes the page links are being extracted from
+type linkOrigin struct {
+	sourcePage string
+	depth      int
+	nofollow   bool // the page asks not to follow any of its links
+}
+
+// from returns the metadata of a link found in the element s
+func (o linkOrigin) from(s *goquery.Selection) ClassifiedLink {
+	link := ClassifiedLink{
+		SourcePage: o.sourcePage,
+		Depth:      o.depth,
+		Element:    goquery.NodeName(s),
+		Nofollow:   o.nofollow,
+	}
+
+	if rel, exists := s.Attr("rel"); exists {
+		link.Rel = strings.Fields(strings.ToLower(rel))
+		for _, value := range link.Rel {
+			if value == "nofollow" {
+				link.Nofollow = true
+			}
+		}
+	}
+
+	switch link.Element {
+	case "a":
+		link.AnchorText = cleanText(s.Text())
+		if link.AnchorText == "" {
+			// Image links: use the alternative text of the image
+			alt, _ := s.Find("img[alt]").First().Attr("alt")
+			link.AnchorText = cleanText(alt)
+		}
+	case "img":
+		alt, _ := s.Attr("alt")
+		link.AnchorText = cleanText(alt)
+	}
+	return link
+}
+
+// follows reports whether a link may be crawled, given its rel attributes
+func (ls *LinkScraper) follows(link ClassifiedLink) bool {
+	return !(ls.skipNofollow && link.Nofollow)
+}
+
+// pageNofollow reports whether <meta name="robots" content="nofollow"> is set
+func pageNofollow(doc *goquery.Document) bool {
+	nofollow := false
+	doc.Find("meta[name]").Each(func(i int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		if !strings.EqualFold(name, "robots") {
+			return
+		}
+		content, _ := s.Attr("content")
+		for _, directive := range strings.Split(strings.ToLower(content), ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "nofollow" || directive == "none" {
+				nofollow = true
+			}
+		}
+	})
+	return nofollow
+}
+
+// cleanText collapses the whitespace of a text and shortens it
+func cleanText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxAnchorTextLength {
+		text = string([]rune(text)[:maxAnchorTextLength]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upper bounds of the request latency histogram buckets, in seconds
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics records the HTTP requests of the default clients: response status
+// codes, failed requests and latency. Share one between the scrapers to
+// monitor (Config.Metrics, the same for every seed of a Batch).
+type Metrics struct {
+	mutex         sync.Mutex
+	statusCodes   map[int]int
+	failures      int
+	latencyCounts []int // one count per bucket, plus the +Inf one
+	latencySum    float64
+	requests      int
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		statusCodes:   make(map[int]int),
+		latencyCounts: make([]int, len(latencyBuckets)+1),
+	}
+}
+
+func (m *Metrics) observe(resp *http.Response, err error, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.requests++
+	seconds := latency.Seconds()
+	m.latencySum += seconds
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	m.latencyCounts[bucket]++
+
+	if err != nil {
+		m.failures++
+		return
+	}
+	m.statusCodes[resp.StatusCode]++
+}
+
+// metricsTransport records the requests it makes in metrics
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *Metrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.metrics.observe(resp, err, time.Since(start))
+	return resp, err
+}
+
+// MonitorHandler serves the state of the batch while it runs:
+//   - /metrics: Prometheus metrics (pages crawled, links per category, errors,
+//     queue depth, HTTP status codes and request latency)
+//   - /healthz: a liveness check answering {"status":"ok"}
+//   - /status: the live progress of each seed, as JSON
+func (b *Batch) MonitorHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		b.writeMetrics(w)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusJSON(w, map[string]string{"status": "ok", "phase": b.getPhase()})
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatusJSON(w, b.status())
+	})
+	return mux
+}
+
+// SeedStatus is the progress of one seed, as served on /status
+type SeedStatus struct {
+	Seed         string  `json:"seed,omitempty"`
+	PagesVisited int     `json:"pages_visited"`
+	Queued       int     `json:"queued"`
+	Links        int     `json:"links"`
+	Errors       int     `json:"errors"`
+	Requests     int     `json:"requests"`
+	RequestRate  float64 `json:"request_rate"` // requests per second
+	ETA          string  `json:"eta,omitempty"`
+}
+
+// BatchStatus is the progress of a Batch, as served on /status
+type BatchStatus struct {
+	Phase     string `json:"phase"` // starting, crawling, classifying, checking_links, downloading or finished
+	StartedAt string `json:"started_at"`
+	Elapsed   string `json:"elapsed"`
+	SeedStatus
+	Seeds []SeedStatus `json:"seeds"`
+}
+
+func (b *Batch) status() BatchStatus {
+	status := BatchStatus{
+		Phase:      b.getPhase(),
+		StartedAt:  b.startTime.Format(time.RFC3339),
+		Elapsed:    time.Since(b.startTime).Round(time.Second).String(),
+		SeedStatus: newSeedStatus("", b.Progress()),
+		Seeds:      make([]SeedStatus, 0, len(b.scrapers)),
+	}
+	for _, ls := range b.scrapers {
+		status.Seeds = append(status.Seeds, newSeedStatus(ls.baseURL.String(), ls.Progress()))
+	}
+	return status
+}
+
+func newSeedStatus(seed string, progress Progress) SeedStatus {
+	status := SeedStatus{
+		Seed:         seed,
+		PagesVisited: progress.PagesVisited,
+		Queued:       progress.Queued,
+		Links:        progress.Links,
+		Errors:       progress.Errors,
+		Requests:     progress.Requests,
+		RequestRate:  progress.RequestRate(),
+	}
+	if eta, ok := progress.ETA(); ok {
+		status.ETA = eta.Round(time.Second).String()
+	}
+	return status
+}
+
+func writeStatusJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// seedMetrics are the figures of one seed exposed on /metrics
+type seedMetrics struct {
+	seed       string
+	progress   Progress
+	crawled    int // pages done, a counter unlike progress.PagesVisited
+	categories map[LinkCategory]int
+	retries    int
+	failed     int
+}
+
+func (ls *LinkScraper) seedMetrics() seedMetrics {
+	progress := ls.Progress()
+
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	categories := make(map[LinkCategory]int, len(ls.classifiedLinks))
+	for category, links := range ls.classifiedLinks {
+		categories[category] = len(links)
+	}
+	return seedMetrics{
+		seed:       ls.baseURL.String(),
+		progress:   progress,
+		crawled:    ls.pagesCrawled,
+		categories: categories,
+		retries:    ls.retries,
+		failed:     len(ls.failedURLs),
+	}
+}
+
+// writeMetrics writes the metrics in the Prometheus text format
+func (b *Batch) writeMetrics(w io.Writer) {
+	seeds := make([]seedMetrics, 0, len(b.scrapers))
+	for _, ls := range b.scrapers {
+		seeds = append(seeds, ls.seedMetrics())
+	}
+
+	perSeed := func(name, kind, help string, value func(seed seedMetrics) float64) {
+		writeMetricHeader(w, name, kind, help)
+		for _, seed := range seeds {
+			writeMetric(w, name, labels("seed", seed.seed), value(seed))
+		}
+	}
+	perSeed("linkscraper_pages_crawled_total", "counter", "Pages scraped, including the resumed ones", func(seed seedMetrics) float64 {
+		return float64(seed.crawled)
+	})
+	perSeed("linkscraper_queue_depth", "gauge", "Pages waiting in the frontier", func(seed seedMetrics) float64 {
+		return float64(seed.progress.Queued)
+	})
+	perSeed("linkscraper_errors_total", "counter", "Errors recorded in the results", func(seed seedMetrics) float64 {
+		return float64(seed.progress.Errors)
+	})
+	perSeed("linkscraper_requests_total", "counter", "Requests made by this run, retries included", func(seed seedMetrics) float64 {
+		return float64(seed.progress.Requests)
+	})
+	perSeed("linkscraper_retries_total", "counter", "Requests retried after a transient error", func(seed seedMetrics) float64 {
+		return float64(seed.retries)
+	})
+	perSeed("linkscraper_failed_urls", "gauge", "URLs still failing after every attempt", func(seed seedMetrics) float64 {
+		return float64(seed.failed)
+	})
+
+	writeMetricHeader(w, "linkscraper_links", "gauge", "Links recorded, per category")
+	for _, seed := range seeds {
+		for _, category := range Categories {
+			writeMetric(w, "linkscraper_links", labels("seed", seed.seed, "category", string(category)), float64(seed.categories[category]))
+		}
+	}
+
+	writeMetricHeader(w, "linkscraper_phase", "gauge", "Current step of the run (1 for the current one)")
+	current := b.getPhase()
+	for _, phase := range batchPhases {
+		value := 0.0
+		if phase == current {
+			value = 1
+		}
+		writeMetric(w, "linkscraper_phase", labels("phase", phase), value)
+	}
+	writeMetricHeader(w, "linkscraper_start_time_seconds", "gauge", "Start time of the run, in seconds since the epoch")
+	writeMetric(w, "linkscraper_start_time_seconds", "", float64(b.startTime.UnixMilli())/1000)
+
+	if b.metrics != nil {
+		b.metrics.write(w)
+	}
+}
+
+// write writes the HTTP metrics in the Prometheus text format
+func (m *Metrics) write(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	writeMetricHeader(w, "linkscraper_http_responses_total", "counter", "HTTP responses, per status code")
+	codes := make([]int, 0, len(m.statusCodes))
+	for code := range m.statusCodes {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	for _, code := range codes {
+		writeMetric(w, "linkscraper_http_responses_total", labels("code", strconv.Itoa(code)), float64(m.statusCodes[code]))
+	}
+
+	writeMetricHeader(w, "linkscraper_http_request_failures_total", "counter", "HTTP requests that got no response (timeouts, network errors...)")
+	writeMetric(w, "linkscraper_http_request_failures_total", "", float64(m.failures))
+
+	const latency = "linkscraper_http_request_duration_seconds"
+	writeMetricHeader(w, latency, "histogram", "Time to receive the response headers")
+	cumulative := 0
+	for i, bound := range latencyBuckets {
+		cumulative += m.latencyCounts[i]
+		writeMetric(w, latency+"_bucket", labels("le", strconv.FormatFloat(bound, 'g', -1, 64)), float64(cumulative))
+	}
+	writeMetric(w, latency+"_bucket", labels("le", "+Inf"), float64(m.requests))
+	writeMetric(w, latency+"_sum", "", m.latencySum)
+	writeMetric(w, latency+"_count", "", float64(m.requests))
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeMetric(w io.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as a Prometheus label set: {name="value",...}
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
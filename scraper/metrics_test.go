@@ -0,0 +1,223 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrapeMetrics reads /metrics from the monitor handler of b, as series -> value
+func scrapeMetrics(t *testing.T, b *Batch) (map[string]float64, map[string]string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	b.MonitorHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", contentType)
+	}
+
+	values := make(map[string]float64)
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, kind, ok := strings.Cut(strings.TrimPrefix(line, "# TYPE "), " "); ok && strings.HasPrefix(line, "# TYPE ") {
+			types[name] = kind
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		cut := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[cut+1:], 64)
+		if cut < 0 || err != nil {
+			t.Fatalf("invalid metric line %q", line)
+		}
+		values[line[:cut]] = value
+	}
+	return values, types
+}
+
+func newMetricsBatch(t *testing.T, handler http.HandlerFunc) (*Batch, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	b, err := NewBatch(Config{
+		MaxDepth: 2,
+		Metrics:  NewMetrics(),
+		Reporter: NewConsoleReporter(io.Discard),
+	}, []string{server.URL + "/"}, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, server.URL + "/"
+}
+
+func TestMetricsText(t *testing.T) {
+	b, seed := newMetricsBatch(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/a.html">A</a><a href="/missing">Missing</a><img src="/logo.png">`)
+		case "/a.html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/">Home</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	b.Scrape(context.Background())
+	values, types := scrapeMetrics(t, b)
+
+	seedLabel := labels("seed", seed)
+	tests := []struct {
+		series string
+		want   float64
+	}{
+		{"linkscraper_pages_crawled_total" + seedLabel, 3},
+		{"linkscraper_queue_depth" + seedLabel, 0},
+		{"linkscraper_errors_total" + seedLabel, 1},
+		{"linkscraper_requests_total" + seedLabel, 4}, // robots.txt and the 3 pages
+		{"linkscraper_retries_total" + seedLabel, 0},
+		{"linkscraper_failed_urls" + seedLabel, 0},
+		{"linkscraper_links" + labels("seed", seed, "category", "html_pages"), 3},
+		{"linkscraper_links" + labels("seed", seed, "category", "images"), 1},
+		{"linkscraper_links" + labels("seed", seed, "category", "documents"), 0},
+		{"linkscraper_phase" + labels("phase", "crawling"), 1},
+		{"linkscraper_phase" + labels("phase", "finished"), 0},
+		{`linkscraper_http_responses_total{code="200"}`, 2},
+		{`linkscraper_http_responses_total{code="404"}`, 2},
+		{"linkscraper_http_request_failures_total", 0},
+		{`linkscraper_http_request_duration_seconds_bucket{le="+Inf"}`, 4},
+		{"linkscraper_http_request_duration_seconds_count", 4},
+	}
+	for _, test := range tests {
+		got, ok := values[test.series]
+		if !ok {
+			t.Errorf("%s missing", test.series)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s = %v, want %v", test.series, got, test.want)
+		}
+	}
+
+	wantTypes := map[string]string{
+		"linkscraper_pages_crawled_total":           "counter",
+		"linkscraper_queue_depth":                   "gauge",
+		"linkscraper_requests_total":                "counter",
+		"linkscraper_links":                         "gauge",
+		"linkscraper_http_responses_total":          "counter",
+		"linkscraper_http_request_duration_seconds": "histogram",
+	}
+	for name, want := range wantTypes {
+		if got := types[name]; got != want {
+			t.Errorf("type of %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The histogram buckets are cumulative
+	previous := 0.0
+	for _, bound := range latencyBuckets {
+		series := "linkscraper_http_request_duration_seconds_bucket" + labels("le", strconv.FormatFloat(bound, 'g', -1, 64))
+		if values[series] < previous {
+			t.Errorf("%s = %v, lower than the previous bucket (%v)", series, values[series], previous)
+		}
+		previous = values[series]
+	}
+}
+
+// TestPagesCrawledNeverDecreases cancels the crawl while a page is loading:
+// the page is given back to the frontier, but the counter must not go down
+func TestPagesCrawledNeverDecreases(t *testing.T) {
+	loading := make(chan struct{})
+	b, seed := newMetricsBatch(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/slow.html">Slow</a>`)
+		case "/slow.html":
+			close(loading)
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	series := "linkscraper_pages_crawled_total" + labels("seed", seed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Scrape(ctx)
+		close(done)
+	}()
+	select {
+	case <-loading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow page never requested")
+	}
+	during, _ := scrapeMetrics(t, b)
+	cancel()
+	<-done
+	after, _ := scrapeMetrics(t, b)
+
+	if during[series] != 1 || after[series] != 1 {
+		t.Errorf("%s = %v while loading and %v after the cancellation, want 1 and 1", series, during[series], after[series])
+	}
+	if visited := b.Progress().PagesVisited; visited != 1 {
+		t.Errorf("pages visited = %d after the cancellation, want 1", visited)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tests := []struct {
+		pairs []string
+		want  string
+	}{
+		{nil, "{}"},
+		{[]string{"code", "200"}, `{code="200"}`},
+		{[]string{"seed", "https://example.com/", "category", "images"}, `{seed="https://example.com/",category="images"}`},
+		{[]string{"seed", `https://example.com/"quoted"\path` + "\n"}, `{seed="https://example.com/\"quoted\"\\path\n"}`},
+		{[]string{"odd"}, "{}"},
+	}
+	for _, test := range tests {
+		if got := labels(test.pairs...); got != test.want {
+			t.Errorf("labels(%q) = %s, want %s", test.pairs, got, test.want)
+		}
+	}
+}
+
+func TestMonitorStatus(t *testing.T) {
+	b, seed := newMetricsBatch(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<p>Home</p>`)
+	})
+	b.Scrape(context.Background())
+
+	get := func(path string, value any) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		b.MonitorHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if err := json.Unmarshal(recorder.Body.Bytes(), value); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	var health map[string]string
+	get("/healthz", &health)
+	if health["status"] != "ok" || health["phase"] != "crawling" {
+		t.Errorf("/healthz = %v, want status ok in the crawling phase", health)
+	}
+
+	var status BatchStatus
+	get("/status", &status)
+	if status.PagesVisited != 1 || len(status.Seeds) != 1 || status.Seeds[0].Seed != seed {
+		t.Errorf("/status = %+v, want 1 page visited for %s", status, seed)
+	}
+}
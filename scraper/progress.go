@@ -0,0 +1,167 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of a running crawl
+type Progress struct {
+	PagesVisited int
+	Queued       int // pages waiting in the frontier
+	Links        int
+	Errors       int
+	Requests     int // requests made by this run, retries included
+	Elapsed      time.Duration
+
+	pagesThisRun int // pages visited by this run, without the resumed ones
+	maxPages     int
+}
+
+// Progress returns the current progress of the crawl
+func (ls *LinkScraper) Progress() Progress {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+
+	return Progress{
+		PagesVisited: ls.pagesVisited,
+		Queued:       ls.frontier.size(),
+		Links:        len(ls.links),
+		Errors:       len(ls.errors),
+		Requests:     ls.requests,
+		Elapsed:      time.Since(ls.startTime),
+		pagesThisRun: ls.pagesVisited - ls.resumedPages,
+		maxPages:     ls.maxPages,
+	}
+}
+
+// RequestRate returns the requests made per second
+func (p Progress) RequestRate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Requests) / p.Elapsed.Seconds()
+}
+
+// ETA estimates the time left to scrape the queued pages (or the pages left
+// before MaxPages) at the current pace. It is false until a page was scraped.
+// New pages are found along the way, so the estimate usually grows at first.
+func (p Progress) ETA() (time.Duration, bool) {
+	if p.pagesThisRun <= 0 {
+		return 0, false
+	}
+	remaining := p.Queued
+	if p.maxPages > 0 && p.maxPages-p.PagesVisited < remaining {
+		remaining = max(p.maxPages-p.PagesVisited, 0)
+	}
+	perPage := p.Elapsed / time.Duration(p.pagesThisRun)
+	return perPage * time.Duration(remaining), true
+}
+
+func (p Progress) String() string {
+	eta := "?"
+	if d, ok := p.ETA(); ok {
+		eta = d.Round(time.Second).String()
+	}
+	return fmt.Sprintf("⏳ %d pages | %d queued | %d links | %d errors | %.1f req/s | ETA %s",
+		p.PagesVisited, p.Queued, p.Links, p.Errors, p.RequestRate(), eta)
+}
+
+// ProgressReporter prints the events like ConsoleReporter, under a status
+// line showing the progress of the crawl, refreshed in place. It is meant for
+// terminals: the line is redrawn with a carriage return and an ANSI escape code.
+type ProgressReporter struct {
+	mutex    sync.Mutex
+	out      io.Writer
+	console  *ConsoleReporter
+	progress func() Progress
+	status   string
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func NewProgressReporter(out io.Writer) *ProgressReporter {
+	return &ProgressReporter{out: out, console: NewConsoleReporter(out)}
+}
+
+// Start refreshes the status line with progress every interval, until Stop
+// is called. LinkScraper.Progress is the usual progress function.
+func (r *ProgressReporter) Start(progress func() Progress, interval time.Duration) {
+	r.mutex.Lock()
+	r.progress = progress
+	r.stop = make(chan struct{})
+	r.stopped = make(chan struct{})
+	r.mutex.Unlock()
+
+	go func() {
+		defer close(r.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.redraw()
+			select {
+			case <-ticker.C:
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing and clears the status line
+func (r *ProgressReporter) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.stopped
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.clear()
+	r.progress = nil
+	r.status = ""
+}
+
+func (r *ProgressReporter) Report(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Print the event above the status line
+	if event.Type == EventLinkFound {
+		return
+	}
+	r.clear()
+	r.console.Report(event)
+	r.draw()
+}
+
+// redraw refreshes the status line. The progress is read before locking:
+// the scraper must be free to report events while it is being read.
+func (r *ProgressReporter) redraw() {
+	r.mutex.Lock()
+	progress := r.progress
+	r.mutex.Unlock()
+	if progress == nil {
+		return
+	}
+	status := progress().String()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.clear()
+	r.status = status
+	r.draw()
+}
+
+func (r *ProgressReporter) clear() {
+	if r.status != "" {
+		io.WriteString(r.out, "\r\033[K")
+	}
+}
+
+func (r *ProgressReporter) draw() {
+	io.WriteString(r.out, r.status)
+}
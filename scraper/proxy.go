@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	maxProxyFailures = 3           // consecutive failures before a proxy is benched
+	proxyBenchTime   = time.Minute // how long a failing proxy is left aside
+)
+
+// ProxyStats sums up the use of one proxy
+type ProxyStats struct {
+	URL      string `json:"url"` // password hidden
+	Requests int    `json:"requests"`
+	Failures int    `json:"failures"`
+	Benched  int    `json:"benched"` // times the proxy was left aside after repeated failures
+}
+
+type proxyState struct {
+	url          *url.URL
+	stats        ProxyStats
+	failureCount int // consecutive failures
+	benchedUntil time.Time
+}
+
+// proxyPool hands out the proxies in turn, skipping the benched ones
+type proxyPool struct {
+	mutex    sync.Mutex
+	proxies  []*proxyState
+	next     int
+	reporter Reporter
+}
+
+type proxyContextKey struct{}
+
+func newProxyPool(proxies []string, reporter Reporter) (*proxyPool, error) {
+	pool := &proxyPool{reporter: reporter}
+	for _, proxy := range proxies {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %v", proxy, err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid proxy %q: unsupported scheme %q", proxy, proxyURL.Scheme)
+		}
+		pool.proxies = append(pool.proxies, &proxyState{
+			url:   proxyURL,
+			stats: ProxyStats{URL: proxyURL.Redacted()},
+		})
+	}
+	return pool, nil
+}
+
+// pick returns the next available proxy. When every proxy is benched,
+// the one coming back first is used anyway rather than blocking the crawl.
+func (p *proxyPool) pick() *proxyState {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	var soonest *proxyState
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[(p.next+i)%len(p.proxies)]
+		if !proxy.benchedUntil.After(now) {
+			p.next = (p.next + i + 1) % len(p.proxies)
+			return proxy
+		}
+		if soonest == nil || proxy.benchedUntil.Before(soonest.benchedUntil) {
+			soonest = proxy
+		}
+	}
+	return soonest
+}
+
+// record updates the proxy statistics after a request and benches the
+// proxy once it failed maxProxyFailures times in a row
+func (p *proxyPool) record(proxy *proxyState, failed bool) {
+	p.mutex.Lock()
+	proxy.stats.Requests++
+	if !failed {
+		proxy.failureCount = 0
+		p.mutex.Unlock()
+		return
+	}
+
+	proxy.stats.Failures++
+	proxy.failureCount++
+	benched := proxy.failureCount >= maxProxyFailures
+	if benched {
+		proxy.failureCount = 0
+		proxy.benchedUntil = time.Now().Add(proxyBenchTime)
+		proxy.stats.Benched++
+	}
+	p.mutex.Unlock()
+
+	if benched {
+		p.reporter.Report(Event{
+			Type:    EventMessage,
+			Time:    time.Now(),
+			Message: fmt.Sprintf("🚫 Proxy %s benched for %s after %d failures", proxy.stats.URL, proxyBenchTime, maxProxyFailures),
+		})
+	}
+}
+
+func (p *proxyPool) stats() []ProxyStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := make([]ProxyStats, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		stats = append(stats, proxy.stats)
+	}
+	return stats
+}
+
+// proxyFromContext is the Proxy function of the transport: it returns the
+// proxy chosen for the request by proxyTransport
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	if proxy, ok := req.Context().Value(proxyContextKey{}).(*proxyState); ok {
+		return proxy.url, nil
+	}
+	return nil, nil
+}
+
+// proxyTransport picks a proxy for every request and records its outcome
+type proxyTransport struct {
+	base http.RoundTripper
+	pool *proxyPool
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxy := t.pool.pick()
+	req = req.WithContext(context.WithValue(req.Context(), proxyContextKey{}, proxy))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+		// Cancelled by the caller, not the proxy's fault. Client timeouts
+		// end the context with DeadlineExceeded and count as failures.
+		return resp, err
+	}
+	t.pool.record(proxy, err != nil || resp.StatusCode == http.StatusProxyAuthRequired)
+	return resp, err
+}
@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProxy is an HTTP proxy answering every request itself
+type countingProxy struct {
+	*httptest.Server
+	hits atomic.Int32
+}
+
+func newCountingProxy(t *testing.T, handler http.HandlerFunc) *countingProxy {
+	t.Helper()
+	proxy := &countingProxy{}
+	proxy.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "ok")
+}
+
+// hangingHandler never answers before the client gives up
+func hangingHandler(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(5 * time.Second):
+	}
+}
+
+// closedServerURL returns the URL of a server that no longer accepts connections
+func closedServerURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func newProxyScraper(t *testing.T, proxies ...string) *LinkScraper {
+	t.Helper()
+	ls, err := New(Config{
+		BaseURL:  "http://example.test/",
+		Proxies:  proxies,
+		Timeout:  100 * time.Millisecond,
+		Reporter: NewConsoleReporter(io.Discard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls
+}
+
+// get requests a page through the proxies of ls
+func get(ctx context.Context, ls *LinkScraper) error {
+	req, err := ls.newRequest(ctx, http.MethodGet, "http://example.test/page")
+	if err != nil {
+		return err
+	}
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestProxyRotation(t *testing.T) {
+	first := newCountingProxy(t, okHandler)
+	second := newCountingProxy(t, okHandler)
+	ls := newProxyScraper(t, first.URL, second.URL)
+
+	for i := 0; i < 4; i++ {
+		if err := get(context.Background(), ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.hits.Load() != 2 || second.hits.Load() != 2 {
+		t.Errorf("hits = %d and %d, want 2 and 2", first.hits.Load(), second.hits.Load())
+	}
+	for _, stats := range ls.proxies.stats() {
+		if stats.Requests != 2 || stats.Failures != 0 || stats.Benched != 0 {
+			t.Errorf("stats of %s = %+v, want 2 requests and no failure", stats.URL, stats)
+		}
+	}
+}
+
+func TestProxyFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   func(t *testing.T) string
+		request int
+		want    ProxyStats
+	}{
+		{"connection refused", func(t *testing.T) string { return closedServerURL() }, 3, ProxyStats{Requests: 3, Failures: 3, Benched: 1}},
+		{"timeout", func(t *testing.T) string { return newCountingProxy(t, hangingHandler).URL }, 3, ProxyStats{Requests: 3, Failures: 3, Benched: 1}},
+		{"proxy auth required", func(t *testing.T) string {
+			return newCountingProxy(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+			}).URL
+		}, 2, ProxyStats{Requests: 2, Failures: 2}},
+		{"success resets the failure count", func(t *testing.T) string {
+			var calls atomic.Int32
+			return newCountingProxy(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1)%2 == 0 {
+					w.WriteHeader(http.StatusProxyAuthRequired)
+				}
+			}).URL
+		}, 6, ProxyStats{Requests: 6, Failures: 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls := newProxyScraper(t, test.proxy(t))
+			for i := 0; i < test.request; i++ {
+				get(context.Background(), ls)
+			}
+			got := ls.proxies.stats()[0]
+			got.URL = ""
+			if got != test.want {
+				t.Errorf("stats = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProxyBench(t *testing.T) {
+	failing := closedServerURL()
+	good := newCountingProxy(t, okHandler)
+	ls := newProxyScraper(t, failing, good.URL)
+
+	// Every other request goes to the failing proxy until it is benched
+	for i := 0; i < 10; i++ {
+		get(context.Background(), ls)
+	}
+	stats := ls.proxies.stats()
+	if stats[0].Failures != maxProxyFailures || stats[0].Benched != 1 {
+		t.Errorf("failing proxy stats = %+v, want %d failures and benched once", stats[0], maxProxyFailures)
+	}
+	if got := good.hits.Load(); got != 10-maxProxyFailures {
+		t.Errorf("good proxy hits = %d, want %d", got, 10-maxProxyFailures)
+	}
+}
+
+func TestProxyBenchedPoolStillServes(t *testing.T) {
+	ls := newProxyScraper(t, closedServerURL())
+	for i := 0; i < maxProxyFailures+2; i++ {
+		get(context.Background(), ls)
+	}
+	// The only proxy is benched but still used rather than blocking the crawl
+	if got := ls.proxies.stats()[0].Requests; got != maxProxyFailures+2 {
+		t.Errorf("requests = %d, want %d", got, maxProxyFailures+2)
+	}
+}
+
+func TestProxyCancelledRequestNotRecorded(t *testing.T) {
+	proxy := newCountingProxy(t, hangingHandler)
+	ls := newProxyScraper(t, proxy.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := get(ctx, ls); err == nil {
+		t.Fatal("cancelled request succeeded")
+	}
+	if got := ls.proxies.stats()[0]; got.Requests != 0 || got.Failures != 0 {
+		t.Errorf("stats = %+v, want nothing recorded", got)
+	}
+}
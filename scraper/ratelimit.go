@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter paces requests per host, with an optional global cap shared by all hosts.
+// It is safe for concurrent use: every worker goes through the same limiter,
+// each call reserving the next free slot before sleeping until it comes.
+type rateLimiter struct {
+	mutex      sync.Mutex
+	perHost    time.Duration // minimal interval between two requests to the same host
+	global     time.Duration // minimal interval between two requests, whatever the host
+	jitter     float64       // random extra delay, as a fraction of the per-host interval
+	nextHost   map[string]time.Time
+	nextGlobal time.Time
+}
+
+// newRateLimiter builds a limiter from rates expressed in requests per second.
+// A rate of 0 means no limit.
+func newRateLimiter(hostRate, globalRate, jitter float64) *rateLimiter {
+	return &rateLimiter{
+		perHost:  rateToInterval(hostRate),
+		global:   rateToInterval(globalRate),
+		jitter:   jitter,
+		nextHost: make(map[string]time.Time),
+	}
+}
+
+func rateToInterval(rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// wait blocks until a request to host may be sent, or until ctx is cancelled.
+// minInterval raises the per-host interval for this host (e.g. robots.txt Crawl-delay).
+func (rl *rateLimiter) wait(ctx context.Context, host string, minInterval time.Duration) error {
+	host = strings.ToLower(host)
+
+	interval := rl.perHost
+	if minInterval > interval {
+		interval = minInterval
+	}
+	if interval == 0 && rl.global == 0 {
+		return ctx.Err()
+	}
+	if interval > 0 && rl.jitter > 0 {
+		interval += time.Duration(rand.Float64() * rl.jitter * float64(interval))
+	}
+
+	rl.mutex.Lock()
+	start := time.Now()
+	if next := rl.nextHost[host]; next.After(start) {
+		start = next
+	}
+	if rl.nextGlobal.After(start) {
+		start = rl.nextGlobal
+	}
+	rl.nextHost[host] = start.Add(interval)
+	rl.nextGlobal = start.Add(rl.global)
+	rl.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
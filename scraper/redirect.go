@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Longest redirect chain followed for a page
+const maxRedirects = 10
+
+// Redirect is one hop of a redirect chain: the URL requested and the status
+// code of its redirect response
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// RedirectedPage is a crawled URL that redirected to another one
+type RedirectedPage struct {
+	URL       string     `json:"url"`
+	FinalURL  string     `json:"final_url"`
+	Redirects []Redirect `json:"redirects"`
+}
+
+// DuplicatePage is a crawled URL whose content was already scraped under
+// another URL, reached through a redirect or declared as canonical
+type DuplicatePage struct {
+	URL         string `json:"url"`
+	DuplicateOf string `json:"duplicate_of"`
+}
+
+// errRedirectLoop is returned for pages redirecting to a URL already in their chain
+type errRedirectLoop struct {
+	chain []string
+}
+
+func (e errRedirectLoop) Error() string {
+	return "redirect loop: " + strings.Join(e.chain, " → ")
+}
+
+// errDisallowedRedirect is returned for pages redirecting to a URL that robots.txt disallows
+type errDisallowedRedirect struct {
+	url string
+}
+
+func (e errDisallowedRedirect) Error() string {
+	return "redirect to " + e.url + " disallowed by robots.txt"
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case 301, 302, 303, 307, 308:
+		return true
+	}
+	return false
+}
+
+// claimPage marks the content at link as scraped by the crawled page
+// scrapedAs. When it already was, it returns false and the page that did.
+// The URL is marked as visited too, so that it is not queued later.
+func (ls *LinkScraper) claimPage(link, scrapedAs string) (string, bool) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	key := ls.canonicalKey(link)
+	if owner, exists := ls.scrapedPages[key]; exists {
+		return owner, false
+	}
+	ls.scrapedPages[key] = scrapedAs
+	ls.visitedURL[key] = true
+	return scrapedAs, true
+}
+
+func (ls *LinkScraper) addRedirect(link string, page *Page) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	ls.redirectedPages = append(ls.redirectedPages, RedirectedPage{
+		URL:       link,
+		FinalURL:  page.URL,
+		Redirects: page.Redirects,
+	})
+}
+
+func (ls *LinkScraper) addDuplicate(link, duplicateOf string) {
+	ls.mutex.Lock()
+	ls.duplicatePages = append(ls.duplicatePages, DuplicatePage{URL: link, DuplicateOf: duplicateOf})
+	ls.mutex.Unlock()
+	ls.logf("♊ Duplicate page: %s (same as %s)", link, duplicateOf)
+}
+
+// canonicalURL returns the internal URL declared by <link rel="canonical">, if any
+func (ls *LinkScraper) canonicalURL(doc *goquery.Document, baseURL string) string {
+	href, exists := doc.Find("link[rel='canonical'][href]").First().Attr("href")
+	if !exists {
+		return ""
+	}
+	canonical := NormalizeURL(href, baseURL)
+	if canonical == "" || !ls.isInternalLink(canonical) {
+		return ""
+	}
+	return canonical
+}
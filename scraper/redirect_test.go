@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// wrappingFetcher wraps the errors of another fetcher, as a custom Fetcher could
+type wrappingFetcher struct {
+	base Fetcher
+}
+
+func (f wrappingFetcher) Fetch(ctx context.Context, link string) (*Page, error) {
+	page, err := f.base.Fetch(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped: %w", err)
+	}
+	return page, nil
+}
+
+func redirectSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			io.WriteString(w, "User-agent: *\nDisallow: /private")
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/moved.html">Moved</a><a href="/loop-a.html">Loop</a><a href="/to-private.html">Private</a>`)
+		case "/moved.html":
+			http.Redirect(w, r, "/new.html", http.StatusMovedPermanently)
+		case "/loop-a.html":
+			http.Redirect(w, r, "/loop-b.html", http.StatusFound)
+		case "/loop-b.html":
+			http.Redirect(w, r, "/loop-a.html", http.StatusFound)
+		case "/to-private.html":
+			http.Redirect(w, r, "/private/page.html", http.StatusFound)
+		case "/new.html", "/private/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<p>page</p>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedirectOutcomes(t *testing.T) {
+	server := redirectSite(t)
+	tests := []struct {
+		name string
+		wrap bool
+	}{
+		{"default fetcher", false},
+		{"wrapped errors", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ls, err := New(Config{
+				BaseURL:  server.URL + "/",
+				MaxDepth: 1,
+				Reporter: NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wrap {
+				ls.fetcher = wrappingFetcher{base: ls.fetcher}
+			}
+			ls.Scrape(context.Background())
+			results := ls.Results()
+
+			if got := results.Statistics.RedirectLoops; got != 1 {
+				t.Errorf("redirect loops = %d, want 1", got)
+			}
+			if got := results.Statistics.RedirectedCount; got != 1 {
+				t.Errorf("redirected pages = %d, want 1", got)
+			}
+			if got := results.DisallowedURLs; len(got) != 1 || got[0] != server.URL+"/private/page.html" {
+				t.Errorf("disallowed URLs = %q, want the redirect target", got)
+			}
+			if len(results.Errors) != 1 {
+				t.Errorf("errors = %q, want the redirect loop only", results.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// RenderOptions configures a RenderFetcher
+type RenderOptions struct {
+	ExecPath    string            // Chrome or Chromium binary (default: looked up in the usual places)
+	Headers     map[string]string // added to every request made by the browser, whatever its host
+	Proxy       string            // proxy server used by the browser, without credentials
+	Timeout     time.Duration     // maximum time to load a page (default: 30s)
+	IdleTimeout time.Duration     // maximum wait for the network to go idle once loaded (default: 10s)
+}
+
+// RenderFetcher loads pages in headless Chrome, so that the links added by
+// JavaScript (React, Vue... applications) are part of the extracted HTML.
+// A page is read once the browser reports its network as idle, or after IdleTimeout.
+// The browser has its own cookies, without those of the cookie jar or a login.
+// Close must be called to stop the browser.
+type RenderFetcher struct {
+	opts          RenderOptions
+	browserCtx    context.Context
+	cancelAlloc   context.CancelFunc
+	cancelBrowser context.CancelFunc
+}
+
+// NewRenderFetcher starts a headless browser
+func NewRenderFetcher(opts RenderOptions) (*RenderFetcher, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 10 * time.Second
+	}
+
+	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserAgent(userAgent),
+		chromedp.Flag("ignore-certificate-errors", true),
+	)
+	if opts.ExecPath != "" {
+		allocOpts = append(allocOpts, chromedp.ExecPath(opts.ExecPath))
+	}
+	if opts.Proxy != "" {
+		allocOpts = append(allocOpts, chromedp.ProxyServer(opts.Proxy))
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+
+	// Start the browser right away so that a missing Chrome is reported before the crawl
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, fmt.Errorf("error starting headless browser: %v", err)
+	}
+
+	return &RenderFetcher{
+		opts:          opts,
+		browserCtx:    browserCtx,
+		cancelAlloc:   cancelAlloc,
+		cancelBrowser: cancelBrowser,
+	}, nil
+}
+
+// Close stops the browser
+func (f *RenderFetcher) Close() {
+	f.cancelBrowser()
+	f.cancelAlloc()
+}
+
+// Fetch loads the page in a new tab and returns the rendered DOM
+func (f *RenderFetcher) Fetch(ctx context.Context, link string) (*Page, error) {
+	tabCtx, cancel := chromedp.NewContext(f.browserCtx)
+	defer cancel()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, f.opts.Timeout)
+	defer cancelTimeout()
+	stopWatching := context.AfterFunc(ctx, cancel)
+	defer stopWatching()
+
+	idle := f.watchNetworkIdle(tabCtx)
+
+	headers := network.Headers{}
+	for name, value := range f.opts.Headers {
+		headers[name] = value
+	}
+	err := chromedp.Run(tabCtx, network.Enable(), network.SetExtraHTTPHeaders(headers))
+	if err != nil {
+		return nil, f.loadError(ctx, err)
+	}
+
+	resp, err := chromedp.RunResponse(tabCtx, chromedp.Navigate(link))
+	if err != nil {
+		return nil, f.loadError(ctx, err)
+	}
+	if resp != nil {
+		if resp.Status < 200 || resp.Status >= 300 {
+			return nil, StatusError{StatusCode: int(resp.Status)}
+		}
+		if !strings.Contains(strings.ToLower(resp.MimeType), "text/html") {
+			return nil, errNonHTML{contentType: resp.MimeType}
+		}
+	}
+
+	// Scripts may still be loading content after the load event
+	timer := time.NewTimer(f.opts.IdleTimeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+	case <-timer.C:
+	case <-tabCtx.Done():
+		return nil, f.loadError(ctx, tabCtx.Err())
+	}
+
+	var location, html string
+	err = chromedp.Run(tabCtx,
+		chromedp.Location(&location),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		return nil, f.loadError(ctx, err)
+	}
+	return &Page{URL: location, HTML: []byte(html)}, nil
+}
+
+// watchNetworkIdle returns a channel receiving a value once the page last
+// navigated to in the tab's main frame has no more network activity
+func (f *RenderFetcher) watchNetworkIdle(tabCtx context.Context) <-chan struct{} {
+	idle := make(chan struct{}, 1)
+	var mutex sync.Mutex
+	var currentLoader cdp.LoaderID
+
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		event, ok := ev.(*page.EventLifecycleEvent)
+		if !ok || event.FrameID != cdp.FrameID(chromedp.FromContext(tabCtx).Target.TargetID) {
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch event.Name {
+		case "init":
+			// A new document is loading, forget the idle state of the previous one
+			currentLoader = event.LoaderID
+			select {
+			case <-idle:
+			default:
+			}
+		case "networkIdle":
+			if event.LoaderID == currentLoader {
+				select {
+				case idle <- struct{}{}:
+				default:
+				}
+			}
+		}
+	})
+	return idle
+}
+
+// loadError reports the caller's cancellation as is, and wraps the other errors
+func (f *RenderFetcher) loadError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("error rendering page: %v", err)
+}
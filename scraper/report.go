@@ -0,0 +1,394 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReportFormat is a human readable report written by SaveResults, next to the output formats
+type ReportFormat string
+
+const (
+	// ReportHTML writes report.html, a self-contained page with charts and sortable tables
+	ReportHTML ReportFormat = "html"
+	// ReportMarkdown writes report.md, a summary of the figures
+	ReportMarkdown ReportFormat = "md"
+)
+
+// ReportFormats lists every supported report format
+var ReportFormats = []ReportFormat{ReportHTML, ReportMarkdown}
+
+func (f ReportFormat) valid() bool {
+	for _, format := range ReportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// reportData is what the reports are rendered from
+type reportData struct {
+	Title      string
+	Results    ScrapingResults
+	Categories []reportCategory
+	Depths     []reportDepth
+	Links      []reportLink
+	Broken     []reportStatus
+}
+
+type reportCategory struct {
+	Name  string
+	Icon  string
+	Count int
+	Share float64 // percentage of all the links
+	Width float64 // bar width, as a percentage of the biggest category
+}
+
+type reportDepth struct {
+	Depth    int
+	Links    int
+	Internal int
+	External int
+}
+
+type reportStatus struct {
+	LinkStatus
+	Status string
+}
+
+type reportLink struct {
+	linkRecord
+	Status string // checked status, when links were checked
+	Broken bool
+}
+
+func newReportData(results ScrapingResults) reportData {
+	data := reportData{
+		Title:   results.BaseURL,
+		Results: results,
+	}
+	for _, status := range brokenLinks(results.CheckedLinks) {
+		data.Broken = append(data.Broken, reportStatus{LinkStatus: status, Status: status.describe()})
+	}
+	if len(results.Seeds) > 0 {
+		data.Title = strings.Join(results.Seeds, ", ")
+	}
+
+	biggest := 0
+	for _, category := range Categories {
+		biggest = max(biggest, results.CategorySummary[category])
+	}
+	for _, category := range Categories {
+		count := results.CategorySummary[category]
+		if count == 0 {
+			continue
+		}
+		data.Categories = append(data.Categories, reportCategory{
+			Name:  string(category),
+			Icon:  categoryIcons[category],
+			Count: count,
+			Share: 100 * float64(count) / float64(max(results.TotalLinks, 1)),
+			Width: 100 * float64(count) / float64(biggest),
+		})
+	}
+
+	statuses := make(map[string]LinkStatus, len(results.CheckedLinks))
+	for _, status := range results.CheckedLinks {
+		statuses[status.URL] = status
+	}
+	depths := make(map[int]*reportDepth)
+	for _, record := range linkRecords(results) {
+		link := reportLink{linkRecord: record}
+		if status, checked := statuses[record.URL]; checked {
+			link.Status = status.describe()
+			if status.StatusCode > 0 && status.Error == "" {
+				link.Status = strconv.Itoa(status.StatusCode)
+			}
+			link.Broken = status.Broken()
+		}
+		data.Links = append(data.Links, link)
+
+		depth, exists := depths[record.Depth]
+		if !exists {
+			depth = &reportDepth{Depth: record.Depth}
+			depths[record.Depth] = depth
+		}
+		depth.Links++
+		if record.Scope == "internal" {
+			depth.Internal++
+		} else {
+			depth.External++
+		}
+	}
+	for _, depth := range depths {
+		data.Depths = append(data.Depths, *depth)
+	}
+	sort.Slice(data.Depths, func(i, j int) bool { return data.Depths[i].Depth < data.Depths[j].Depth })
+	return data
+}
+
+func writeReport(sessionDir string, format ReportFormat, results ScrapingResults) error {
+	switch format {
+	case ReportHTML:
+		return writeHTMLReport(filepath.Join(sessionDir, "report.html"), newReportData(results))
+	case ReportMarkdown:
+		return writeMarkdownReport(filepath.Join(sessionDir, "report.md"), newReportData(results))
+	}
+	return fmt.Errorf("unknown report format: %q", format)
+}
+
+func writeHTMLReport(path string, data reportData) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating HTML report: %v", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := htmlReport.Execute(writer, data); err != nil {
+		return fmt.Errorf("error writing HTML report: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing HTML report: %v", err)
+	}
+	return file.Close()
+}
+
+func writeMarkdownReport(path string, data reportData) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating Markdown report: %v", err)
+	}
+	defer file.Close()
+
+	results := data.Results
+	stats := results.Statistics
+	w := bufio.NewWriter(file)
+
+	fmt.Fprintf(w, "# 🔗 Link report: %s\n\n", data.Title)
+	fmt.Fprintf(w, "Generated on %s, execution time %s.\n\n", results.Timestamp, stats.ExecutionTime)
+
+	fmt.Fprintf(w, "## 📊 Summary\n\n")
+	fmt.Fprintf(w, "| Metric | Value |\n|---|---:|\n")
+	fmt.Fprintf(w, "| Pages visited | %d |\n", stats.PagesVisited)
+	fmt.Fprintf(w, "| Total links | %d |\n", stats.TotalLinks)
+	fmt.Fprintf(w, "| Internal links | %d |\n", stats.InternalCount)
+	fmt.Fprintf(w, "| External links | %d |\n", stats.ExternalCount)
+	fmt.Fprintf(w, "| Max depth reached | %d |\n", stats.MaxDepthReached)
+	fmt.Fprintf(w, "| Errors | %d |\n", stats.ErrorsCount)
+	fmt.Fprintf(w, "| Disallowed by robots.txt | %d |\n", stats.DisallowedCount)
+	fmt.Fprintf(w, "| Redirected pages | %d |\n", stats.RedirectedCount)
+	fmt.Fprintf(w, "| Duplicate pages | %d |\n", stats.DuplicateCount)
+	fmt.Fprintf(w, "| Collapsed URL variants | %d |\n", stats.CollapsedCount)
+	fmt.Fprintf(w, "| Pages still failing after retries | %d |\n", stats.FailedCount)
+	if contentTypes := stats.ContentTypes; contentTypes != nil {
+		fmt.Fprintf(w, "| Reclassified by Content-Type | %d of %d |\n", contentTypes.Reclassified, contentTypes.Checked)
+	}
+	if check := stats.LinkCheck; check != nil {
+		if check.Partial {
+			fmt.Fprintf(w, "| Checked links | %d (interrupted) |\n", check.Checked)
+		} else {
+			fmt.Fprintf(w, "| Checked links | %d |\n", check.Checked)
+		}
+		fmt.Fprintf(w, "| Broken links | %d |\n", check.Broken)
+	}
+
+	fmt.Fprintf(w, "\n## 📂 Links by category\n\n")
+	fmt.Fprintf(w, "| Category | Links | Share |\n|---|---:|---:|\n")
+	for _, category := range data.Categories {
+		fmt.Fprintf(w, "| %s %s | %d | %.1f%% |\n", category.Icon, category.Name, category.Count, category.Share)
+	}
+
+	fmt.Fprintf(w, "\n## 🪜 Links by depth\n\n")
+	fmt.Fprintf(w, "| Depth | Links | Internal | External |\n|---:|---:|---:|---:|\n")
+	for _, depth := range data.Depths {
+		fmt.Fprintf(w, "| %d | %d | %d | %d |\n", depth.Depth, depth.Links, depth.Internal, depth.External)
+	}
+
+	if len(results.Domains) > 0 {
+		fmt.Fprintf(w, "\n## 🌍 Domains\n\n")
+		fmt.Fprintf(w, "| Domain | Pages | Links | Internal | External | Errors |\n|---|---:|---:|---:|---:|---:|\n")
+		for _, domain := range results.Domains {
+			fmt.Fprintf(w, "| %s | %d | %d | %d | %d | %d |\n", markdownCell(domain.Domain),
+				domain.PagesVisited, domain.TotalLinks, domain.InternalCount, domain.ExternalCount, domain.ErrorsCount)
+		}
+	}
+
+	if len(data.Broken) > 0 {
+		fmt.Fprintf(w, "\n## 💔 Broken links\n\n")
+		fmt.Fprintf(w, "| URL | Status | Response time |\n|---|---|---:|\n")
+		for _, status := range data.Broken {
+			fmt.Fprintf(w, "| %s | %s | %s |\n", markdownCell(status.URL), markdownCell(status.Status), status.ResponseTime)
+		}
+	}
+
+	if len(results.FailedURLs) > 0 {
+		fmt.Fprintf(w, "\n## 🔁 Pages still failing after retries\n\n")
+		for _, page := range results.FailedURLs {
+			fmt.Fprintf(w, "- %s (%d attempts: %s)\n", page.URL, page.Attempts, page.Error)
+		}
+	}
+
+	if len(results.Errors) > 0 {
+		fmt.Fprintf(w, "\n## 🚨 Errors\n\n")
+		for _, err := range results.Errors {
+			fmt.Fprintf(w, "- %s\n", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("error writing Markdown report: %v", err)
+	}
+	return file.Close()
+}
+
+// markdownCell escapes the characters that would break a Markdown table
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Link report: {{.Title}}</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; margin: 2rem; color: #222; }
+h1 { margin-bottom: 0; }
+h2 { margin-top: 2.5rem; border-bottom: 2px solid #eee; padding-bottom: .3rem; }
+.meta { color: #666; margin-top: .3rem; }
+.cards { display: flex; flex-wrap: wrap; gap: 1rem; margin: 1.5rem 0; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: .75rem 1rem; min-width: 9rem; }
+.card .value { font-size: 1.6rem; font-weight: bold; }
+.card.alert { border-color: #e6a09a; background: #fdf1f0; }
+.card.alert .value { color: #c0392b; }
+.bar { display: flex; align-items: center; gap: .6rem; margin: .35rem 0; }
+.bar .label { width: 11rem; }
+.bar .track { flex: 1; max-width: 40rem; }
+.bar .fill { background: #3498db; height: 1.1rem; border-radius: 3px; min-width: 2px; }
+table { border-collapse: collapse; width: 100%; margin: 1rem 0; font-size: .9rem; }
+th, td { border-bottom: 1px solid #eee; padding: .35rem .5rem; text-align: left; vertical-align: top; }
+th { background: #f6f6f6; cursor: pointer; user-select: none; white-space: nowrap; }
+th.asc::after { content: " ▲"; }
+th.desc::after { content: " ▼"; }
+td.url { word-break: break-all; }
+tr.broken td { background: #fdecea; }
+tr.broken td.status { color: #c0392b; font-weight: bold; }
+input.filter { padding: .4rem .6rem; width: 22rem; max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>🔗 Link report</h1>
+<p class="meta">{{.Title}} · {{.Results.Timestamp}} · execution time {{.Results.Statistics.ExecutionTime}}</p>
+
+{{with .Results.Statistics}}
+<div class="cards">
+  <div class="card"><div class="value">{{.PagesVisited}}</div>pages visited</div>
+  <div class="card"><div class="value">{{.TotalLinks}}</div>links</div>
+  <div class="card"><div class="value">{{.InternalCount}}</div>internal</div>
+  <div class="card"><div class="value">{{.ExternalCount}}</div>external</div>
+  <div class="card"><div class="value">{{.MaxDepthReached}}</div>max depth</div>
+  <div class="card{{if .ErrorsCount}} alert{{end}}"><div class="value">{{.ErrorsCount}}</div>errors</div>
+  {{if .ContentTypes}}<div class="card"><div class="value">{{.ContentTypes.Reclassified}}</div>reclassified by Content-Type</div>{{end}}
+  {{if .LinkCheck}}<div class="card{{if .LinkCheck.Broken}} alert{{end}}"><div class="value">{{.LinkCheck.Broken}}</div>broken links</div>{{end}}
+  {{if .FailedCount}}<div class="card alert"><div class="value">{{.FailedCount}}</div>failed pages</div>{{end}}
+</div>
+{{end}}
+
+<h2>📂 Links by category</h2>
+{{range .Categories}}
+<div class="bar">
+  <span class="label">{{.Icon}} {{.Name}}</span>
+  <span class="track"><div class="fill" style="width: {{printf "%.1f" .Width}}%"></div></span>
+  <span>{{.Count}} ({{printf "%.1f" .Share}}%)</span>
+</div>
+{{end}}
+
+<h2>🪜 Links by depth</h2>
+<table class="sortable">
+<thead><tr><th>Depth</th><th>Links</th><th>Internal</th><th>External</th></tr></thead>
+<tbody>
+{{range .Depths}}<tr><td>{{.Depth}}</td><td>{{.Links}}</td><td>{{.Internal}}</td><td>{{.External}}</td></tr>
+{{end}}</tbody>
+</table>
+
+{{if .Results.Domains}}
+<h2>🌍 Domains</h2>
+<table class="sortable">
+<thead><tr><th>Domain</th><th>Pages</th><th>Links</th><th>Internal</th><th>External</th><th>Errors</th></tr></thead>
+<tbody>
+{{range .Results.Domains}}<tr><td>{{.Domain}}</td><td>{{.PagesVisited}}</td><td>{{.TotalLinks}}</td><td>{{.InternalCount}}</td><td>{{.ExternalCount}}</td><td>{{.ErrorsCount}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+
+{{if .Broken}}
+<h2>💔 Broken links ({{len .Broken}})</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Redirect</th><th>Response time</th></tr></thead>
+<tbody>
+{{range .Broken}}<tr class="broken"><td class="url"><a href="{{.URL}}">{{.URL}}</a></td><td class="status">{{.Status}}</td><td class="url">{{.RedirectTo}}</td><td>{{.ResponseTime}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+
+<h2>🔗 All links ({{len .Links}})</h2>
+<input class="filter" type="search" placeholder="Filter links..." data-table="links">
+<table class="sortable" id="links">
+<thead><tr><th>URL</th><th>Category</th><th>Type</th><th>Scope</th><th>Depth</th><th>Source page</th><th>Anchor text</th>{{if .Results.CheckedLinks}}<th>Status</th>{{end}}</tr></thead>
+<tbody>
+{{$checked := .Results.CheckedLinks}}{{range .Links}}<tr{{if .Broken}} class="broken"{{end}}><td class="url"><a href="{{.URL}}">{{.URL}}</a></td><td>{{.Category}}</td><td>{{.FileType}}</td><td>{{.Scope}}</td><td>{{.Depth}}</td><td class="url">{{.SourcePage}}</td><td>{{.AnchorText}}</td>{{if $checked}}<td class="status">{{.Status}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+
+{{if .Results.Errors}}
+<h2>🚨 Errors ({{len .Results.Errors}})</h2>
+<ul>
+{{range .Results.Errors}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}
+
+<script>
+// Click a column header to sort the table, numbers first
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  var headers = table.querySelectorAll("th");
+  headers.forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      headers.forEach(function (other) { other.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column].textContent, y = b.cells[column].textContent;
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var order = !isNaN(nx) && !isNaN(ny) ? nx - ny : x.localeCompare(y);
+        return asc ? order : -order;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+
+// Hide the rows not containing the filter text
+document.querySelectorAll("input.filter").forEach(function (input) {
+  var table = document.getElementById(input.dataset.table);
+  input.addEventListener("input", function () {
+    var text = input.value.toLowerCase();
+    Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+      row.style.display = row.textContent.toLowerCase().indexOf(text) >= 0 ? "" : "none";
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
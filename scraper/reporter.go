@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// EventType tells what an Event is about
+type EventType string
+
+const (
+	EventPageStart EventType = "page_start" // a page is about to be loaded
+	EventPageDone  EventType = "page_done"  // a page was loaded and its links extracted
+	EventLinkFound EventType = "link_found" // a new link was recorded
+	EventError     EventType = "error"      // something failed, also listed in the results errors
+	EventMessage   EventType = "message"    // any other information (redirects, retries, downloads...)
+)
+
+// Event is something that happened during a run
+type Event struct {
+	Type    EventType       `json:"type"`
+	Time    time.Time       `json:"time"`
+	Seed    string          `json:"seed,omitempty"` // in a Batch, the website the event belongs to
+	URL     string          `json:"url,omitempty"`
+	Depth   int             `json:"depth"`
+	Links   int             `json:"links,omitempty"`   // page_done: links found on the page
+	Link    *ClassifiedLink `json:"link,omitempty"`    // link_found: the recorded link
+	Message string          `json:"message,omitempty"` // error and message: human readable text
+}
+
+// Reporter receives the events of a run, for display or for other tools.
+// Implementations must be safe for concurrent use by the crawl workers.
+type Reporter interface {
+	Report(event Event)
+}
+
+// report sends an event to the reporter, dated now
+func (ls *LinkScraper) report(event Event) {
+	event.Time = time.Now()
+	ls.reporter.Report(event)
+}
+
+// logf reports a message event
+func (ls *LinkScraper) logf(format string, args ...any) {
+	ls.report(Event{Type: EventMessage, Message: fmt.Sprintf(format, args...)})
+}
+
+// ConsoleReporter prints the events as human readable lines. It is the
+// default Reporter. Link events are not printed, there are too many of them.
+type ConsoleReporter struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+func NewConsoleReporter(out io.Writer) *ConsoleReporter {
+	return &ConsoleReporter{out: out}
+}
+
+func (r *ConsoleReporter) Report(event Event) {
+	var line string
+	switch event.Type {
+	case EventPageStart:
+		line = fmt.Sprintf("🔍 [Depth %d] Scraping: %s\n", event.Depth, event.URL)
+	case EventPageDone:
+		line = fmt.Sprintf("✅ Page loaded successfully: %s\n📊 Total of %d links found on this page\n", event.URL, event.Links)
+	case EventError:
+		line = fmt.Sprintf("❌ ERROR: %s\n", event.Message)
+	case EventMessage:
+		line = event.Message + "\n"
+	default:
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	io.WriteString(r.out, line)
+}
+
+// NDJSONReporter writes every event as a JSON object on its own line, so that
+// other tools can follow the crawl as it goes
+type NDJSONReporter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewNDJSONReporter(out io.Writer) *NDJSONReporter {
+	return &NDJSONReporter{encoder: json.NewEncoder(out)}
+}
+
+func (r *NDJSONReporter) Report(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// A reader going away must not stop the crawl, write errors are ignored
+	r.encoder.Encode(event)
+}
+
+type multiReporter []Reporter
+
+// MultiReporter sends the events to every given reporter, in order
+func MultiReporter(reporters ...Reporter) Reporter {
+	return multiReporter(reporters)
+}
+
+func (m multiReporter) Report(event Event) {
+	for _, reporter := range m {
+		reporter.Report(event)
+	}
+}
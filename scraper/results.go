@@ -0,0 +1,396 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ScrapingResults is the outcome of a crawl, as saved in summary.json
+type ScrapingResults struct {
+	BaseURL         string                            `json:"base_url"`
+	Seeds           []string                          `json:"seeds,omitempty"` // combined report: the crawled websites
+	TotalLinks      int                               `json:"total_links"`
+	InternalLinks   []string                          `json:"internal_links"`
+	ExternalLinks   []string                          `json:"external_links"`
+	AllLinks        []string                          `json:"all_links"`
+	ClassifiedLinks map[LinkCategory][]ClassifiedLink `json:"classified_links"`
+	CategorySummary map[LinkCategory]int              `json:"category_summary"`
+	Errors          []string                          `json:"errors"`
+	DisallowedURLs  []string                          `json:"disallowed_urls"`
+	RedirectedPages []RedirectedPage                  `json:"redirected_pages"`
+	DuplicatePages  []DuplicatePage                   `json:"duplicate_pages"`
+	CollapsedLinks  []CollapsedLink                   `json:"collapsed_links"`
+	RetriedURLs     []string                          `json:"retried_urls"`
+	FailedURLs      []FailedURL                       `json:"failed_urls"`
+	CheckedLinks    []LinkStatus                      `json:"checked_links,omitempty"`
+	URLSources      URLSources                        `json:"url_sources"`
+	Sitemaps        []string                          `json:"sitemaps,omitempty"`
+	Domains         []DomainSummary                   `json:"domains,omitempty"` // combined report: figures per domain
+	Statistics      ScrapingStats                     `json:"statistics"`
+	Timestamp       string                            `json:"timestamp"`
+}
+
+// ScrapingStats holds the figures of a crawl
+type ScrapingStats struct {
+	PagesVisited    int    `json:"pages_visited"`
+	TotalLinks      int    `json:"total_links"`
+	InternalCount   int    `json:"internal_count"`
+	ExternalCount   int    `json:"external_count"`
+	ErrorsCount     int    `json:"errors_count"`
+	DisallowedCount int    `json:"disallowed_count"`
+	RedirectedCount int    `json:"redirected_count"`
+	RedirectLoops   int    `json:"redirect_loops"`
+	DuplicateCount  int    `json:"duplicate_count"`
+	CollapsedCount  int    `json:"collapsed_count"` // URL variants recorded as a single link
+	Retries         int    `json:"retries"`
+	RetriedCount    int    `json:"retried_count"`
+	FailedCount     int    `json:"failed_count"`
+	ExecutionTime   string `json:"execution_time"`
+	MaxDepthReached int    `json:"max_depth_reached"`
+
+	LinkCheck    *LinkCheckStats   `json:"link_check,omitempty"`    // only when links were checked
+	ContentTypes *ContentTypeStats `json:"content_types,omitempty"` // only when links were classified by Content-Type
+	Downloads    *DownloadStats    `json:"downloads,omitempty"`     // only when assets were downloaded
+	Filters      *FilterStats      `json:"filters,omitempty"`       // only when URL filters are set
+	Proxies      []ProxyStats      `json:"proxies,omitempty"`       // only when proxies are used
+}
+
+// cloneClassified copies the links per category, whose slices keep growing during the crawl
+func cloneClassified(classified map[LinkCategory][]ClassifiedLink) map[LinkCategory][]ClassifiedLink {
+	clone := make(map[LinkCategory][]ClassifiedLink, len(classified))
+	for category, links := range classified {
+		clone[category] = slices.Clone(links)
+		for i := range clone[category] {
+			clone[category][i].Rel = slices.Clone(links[i].Rel)
+		}
+	}
+	return clone
+}
+
+// cloneRedirected copies the redirected pages along with their chains
+func cloneRedirected(pages []RedirectedPage) []RedirectedPage {
+	clone := slices.Clone(pages)
+	for i := range clone {
+		clone[i].Redirects = slices.Clone(pages[i].Redirects)
+	}
+	return clone
+}
+
+// cloneStats copies the statistics of a pass, nil when it did not run
+func cloneStats[T any](stats *T) *T {
+	if stats == nil {
+		return nil
+	}
+	clone := *stats
+	return &clone
+}
+
+// Results returns a snapshot of everything collected so far. It is a copy:
+// the crawl can go on while the caller reads it.
+func (ls *LinkScraper) Results() ScrapingResults {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+
+	// Créer un résumé par catégorie
+	categorySummary := make(map[LinkCategory]int)
+	for category, links := range ls.classifiedLinks {
+		categorySummary[category] = len(links)
+	}
+
+	var proxyStats []ProxyStats
+	if ls.proxies != nil {
+		proxyStats = ls.proxies.stats()
+	}
+
+	var filterStats *FilterStats
+	if ls.filter != nil {
+		filterStats = cloneStats(&ls.filterStats)
+	}
+
+	return ScrapingResults{
+		BaseURL:         ls.baseURL.String(),
+		TotalLinks:      len(ls.links),
+		InternalLinks:   slices.Clone(ls.internalLinks),
+		ExternalLinks:   slices.Clone(ls.externalLinks),
+		AllLinks:        slices.Clone(ls.links),
+		ClassifiedLinks: cloneClassified(ls.classifiedLinks),
+		CategorySummary: categorySummary,
+		Errors:          slices.Clone(ls.errors),
+		DisallowedURLs:  slices.Clone(ls.disallowedURLs),
+		RedirectedPages: cloneRedirected(ls.redirectedPages),
+		DuplicatePages:  slices.Clone(ls.duplicatePages),
+		CollapsedLinks:  slices.Clone(ls.collapsedLinks),
+		RetriedURLs:     slices.Clone(ls.retriedURLs),
+		FailedURLs:      slices.Clone(ls.failedURLs),
+		CheckedLinks:    slices.Clone(ls.linkStatuses),
+		URLSources: URLSources{
+			Sitemap: ls.sitemapLinks,
+			HTML:    len(ls.links) - ls.sitemapLinks,
+		},
+		Sitemaps: slices.Clone(ls.sitemaps),
+		Statistics: ScrapingStats{
+			PagesVisited:    ls.pagesVisited,
+			TotalLinks:      len(ls.links),
+			InternalCount:   len(ls.internalLinks),
+			ExternalCount:   len(ls.externalLinks),
+			ErrorsCount:     len(ls.errors),
+			DisallowedCount: len(ls.disallowedURLs),
+			RedirectedCount: len(ls.redirectedPages),
+			RedirectLoops:   ls.redirectLoops,
+			DuplicateCount:  len(ls.duplicatePages),
+			CollapsedCount:  len(ls.collapsedLinks),
+			Retries:         ls.retries,
+			RetriedCount:    len(ls.retriedURLs),
+			FailedCount:     len(ls.failedURLs),
+			ExecutionTime:   time.Since(ls.startTime).String(),
+			MaxDepthReached: ls.currentDepth,
+			LinkCheck:       cloneStats(ls.linkCheckStats),
+			ContentTypes:    cloneStats(ls.typeCheckStats),
+			Downloads:       cloneStats(ls.downloadStats),
+			Filters:         filterStats,
+			Proxies:         proxyStats,
+		},
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+var categoryIcons = map[LinkCategory]string{
+	CategoryHTML:       "📄",
+	CategoryDocument:   "📑",
+	CategoryImage:      "🖼️",
+	CategoryScript:     "⚙️",
+	CategoryStylesheet: "🎨",
+	CategoryMultimedia: "🎬",
+	CategoryArchive:    "📦",
+	CategoryOther:      "❓",
+}
+
+// PrintDetailedStats prints the statistics and a sample of links per category to out
+func (ls *LinkScraper) PrintDetailedStats(out io.Writer) {
+	printDetailedStats(out, ls.Results())
+}
+
+func printDetailedStats(out io.Writer, results ScrapingResults) {
+	fmt.Fprint(out, "\n"+strings.Repeat("=", 50)+"\n")
+	fmt.Fprintf(out, "📊 DETAILED STATISTICS\n")
+	fmt.Fprint(out, strings.Repeat("=", 50)+"\n")
+	if len(results.Seeds) > 0 {
+		fmt.Fprintf(out, "🌐 Websites: %s\n", strings.Join(results.Seeds, ", "))
+	} else {
+		fmt.Fprintf(out, "🌐 Website: %s\n", results.BaseURL)
+	}
+	fmt.Fprintf(out, "⏱️  Execution Time: %s\n", results.Statistics.ExecutionTime)
+	fmt.Fprintf(out, "📄 Pages Visited: %d\n", results.Statistics.PagesVisited)
+	fmt.Fprintf(out, "🔗 Total Links: %d\n", results.Statistics.TotalLinks)
+	fmt.Fprintf(out, "🏠 Internal Links: %d\n", results.Statistics.InternalCount)
+	fmt.Fprintf(out, "🌍 External Links: %d\n", results.Statistics.ExternalCount)
+	if len(results.Sitemaps) > 0 {
+		fmt.Fprintf(out, "🗺️  From Sitemaps: %d (HTML discovery: %d)\n", results.URLSources.Sitemap, results.URLSources.HTML)
+	}
+	fmt.Fprintf(out, "📊 Max Depth Reached: %d\n", results.Statistics.MaxDepthReached)
+	fmt.Fprintf(out, "❌ Errors Encountered: %d\n", results.Statistics.ErrorsCount)
+	fmt.Fprintf(out, "🤖 Disallowed by robots.txt: %d\n", results.Statistics.DisallowedCount)
+	fmt.Fprintf(out, "↪️  Redirected Pages: %d (loops: %d)\n", results.Statistics.RedirectedCount, results.Statistics.RedirectLoops)
+	fmt.Fprintf(out, "♊ Duplicate Pages: %d\n", results.Statistics.DuplicateCount)
+	fmt.Fprintf(out, "🧹 Collapsed URL Variants: %d\n", results.Statistics.CollapsedCount)
+	fmt.Fprintf(out, "🔁 Retries: %d on %d URLs (still failing: %d)\n", results.Statistics.Retries, results.Statistics.RetriedCount, results.Statistics.FailedCount)
+	if filters := results.Statistics.Filters; filters != nil {
+		fmt.Fprintf(out, "🚧 Filtered URLs: %d excluded, %d not included, %d out of scope\n", filters.Excluded, filters.NotIncluded, filters.OutOfScope)
+	}
+
+	// Afficher le résumé par catégorie
+	fmt.Fprintf(out, "\n📂 LINKS BY CATEGORY:\n")
+	for category, count := range results.CategorySummary {
+		if count > 0 {
+			icon := categoryIcons[category]
+			fmt.Fprintf(out, "   %s %s: %d\n", icon, strings.Title(string(category)), count)
+		}
+	}
+
+	// Afficher quelques exemples par catégorie
+	fmt.Fprintf(out, "\n📋 SAMPLE LINKS BY CATEGORY:\n")
+	for category, links := range results.ClassifiedLinks {
+		if len(links) > 0 {
+			icon := categoryIcons[category]
+			fmt.Fprintf(out, "\n%s %s (%d total):\n", icon, strings.Title(string(category)), len(links))
+			// Afficher max 3 exemples par catégorie
+			maxExamples := 3
+			if len(links) < maxExamples {
+				maxExamples = len(links)
+			}
+			for i := 0; i < maxExamples; i++ {
+				fmt.Fprintf(out, "   • [%s] %s\n", links[i].FileType, links[i].URL)
+			}
+			if len(links) > 3 {
+				fmt.Fprintf(out, "   ... and %d more\n", len(links)-3)
+			}
+		}
+	}
+
+	if contentTypes := results.Statistics.ContentTypes; contentTypes != nil {
+		fmt.Fprintf(out, "\n🔬 CONTENT-TYPE CHECK:\n")
+		fmt.Fprintf(out, "   Checked: %d   Reclassified: %d\n", contentTypes.Checked, contentTypes.Reclassified)
+		fmt.Fprintf(out, "   ❓ Unknown type: %d   ❌ Failed: %d   🤖 Disallowed: %d\n", contentTypes.Unknown, contentTypes.Failed, contentTypes.Disallowed)
+	}
+
+	if check := results.Statistics.LinkCheck; check != nil {
+		fmt.Fprintf(out, "\n🩺 LINK CHECK:\n")
+		if check.Partial {
+			fmt.Fprintf(out, "   Checked: %d (interrupted, partial results)\n", check.Checked)
+		} else {
+			fmt.Fprintf(out, "   Checked: %d\n", check.Checked)
+		}
+		fmt.Fprintf(out, "   ✅ 2xx: %d   ↪️  3xx: %d   ⛔ 4xx: %d   💥 5xx: %d\n", check.Status2xx, check.Status3xx, check.Status4xx, check.Status5xx)
+		fmt.Fprintf(out, "   ⏳ Timeouts: %d   ❌ Other errors: %d   🤖 Disallowed: %d\n", check.Timeouts, check.Errors, check.Disallowed)
+		fmt.Fprintf(out, "   💔 Broken links: %d\n", check.Broken)
+	}
+
+	if downloads := results.Statistics.Downloads; downloads != nil {
+		fmt.Fprintf(out, "\n📥 DOWNLOADS:\n")
+		fmt.Fprintf(out, "   Downloaded: %d (%d bytes)\n", downloads.Downloaded, downloads.TotalBytes)
+		fmt.Fprintf(out, "   Skipped: %d   Failed: %d\n", downloads.Skipped, downloads.Failed)
+	}
+
+	if len(results.Statistics.Proxies) > 0 {
+		fmt.Fprintf(out, "\n🌐 PROXIES:\n")
+		for _, proxy := range results.Statistics.Proxies {
+			fmt.Fprintf(out, "   • %s: %d requests, %d failures, benched %d times\n", proxy.URL, proxy.Requests, proxy.Failures, proxy.Benched)
+		}
+	}
+
+	printDomains(out, results.Domains)
+
+	if len(results.Errors) > 0 {
+		fmt.Fprintf(out, "\n🚨 ERRORS:\n")
+		for _, err := range results.Errors {
+			fmt.Fprintf(out, "   • %s\n", err)
+		}
+	}
+
+	fmt.Fprint(out, strings.Repeat("=", 50)+"\n")
+}
+
+func printDomains(out io.Writer, domains []DomainSummary) {
+	if len(domains) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\n🌍 DOMAINS:\n")
+	for _, domain := range domains {
+		fmt.Fprintf(out, "   • %s: %d pages, %d links (%d internal, %d external), %d errors\n",
+			domain.Domain, domain.PagesVisited, domain.TotalLinks, domain.InternalCount, domain.ExternalCount, domain.ErrorsCount)
+	}
+}
+
+// SessionDir returns the timestamped directory, inside the output directory,
+// where the files of this run are written. It is created on first use.
+func (ls *LinkScraper) SessionDir() (string, error) {
+	ls.sessionMutex.Lock()
+	defer ls.sessionMutex.Unlock()
+
+	if ls.sessionDir != "" {
+		return ls.sessionDir, nil
+	}
+	if ls.outputDir == "" {
+		return "", fmt.Errorf("no output directory configured")
+	}
+
+	sessionDir, err := newSessionDir(ls.outputDir, hostFileName(ls.baseURL.Host))
+	if err != nil {
+		return "", err
+	}
+	ls.sessionDir = sessionDir
+	return sessionDir, nil
+}
+
+// hostFileName turns a host into a file name valid on every system:
+// "example.com:8080" becomes "example_com_8080"
+func hostFileName(host string) string {
+	return strings.NewReplacer(".", "_", ":", "_", "[", "", "]", "").Replace(host)
+}
+
+// newSessionDir creates <outputDir>/<name>_<timestamp>. A number is added
+// when it already exists, e.g. for two crawls of a site started the same second.
+func newSessionDir(outputDir, name string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating session directory: %v", err)
+	}
+
+	// Créer un sous-dossier pour cette session
+	base := filepath.Join(outputDir, fmt.Sprintf("%s_%s", name, time.Now().Format("20060102_150405")))
+	sessionDir := base
+	for i := 2; ; i++ {
+		err := os.Mkdir(sessionDir, 0755)
+		if err == nil {
+			return sessionDir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating session directory: %v", err)
+		}
+		sessionDir = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// SaveResults writes the results into a timestamped session directory of the
+// output directory, in every configured output format.
+func (ls *LinkScraper) SaveResults() error {
+	if ls.outputDir == "" {
+		return nil
+	}
+
+	sessionDir, err := ls.SessionDir()
+	if err != nil {
+		return err
+	}
+	if err := saveResults(sessionDir, ls.outputFormats, ls.reports, ls.Results()); err != nil {
+		return err
+	}
+	ls.logf("💾 Results saved to: %s", sessionDir)
+	return nil
+}
+
+// saveResults writes results into sessionDir in the given formats and
+// reports, along with the broken links and failed URLs files
+func saveResults(sessionDir string, formats []OutputFormat, reports []ReportFormat, results ScrapingResults) error {
+	for _, format := range formats {
+		if err := writeFormat(sessionDir, format, results); err != nil {
+			return err
+		}
+	}
+	for _, format := range reports {
+		if err := writeReport(sessionDir, format, results); err != nil {
+			return err
+		}
+	}
+
+	// Sauvegarder les liens cassés si les liens ont été vérifiés
+	if results.Statistics.LinkCheck != nil {
+		brokenFile := filepath.Join(sessionDir, "broken_links.json")
+		brokenData, err := json.MarshalIndent(brokenLinks(results.CheckedLinks), "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %v", err)
+		}
+		err = os.WriteFile(brokenFile, brokenData, 0644)
+		if err != nil {
+			return fmt.Errorf("error writing broken links file: %v", err)
+		}
+	}
+
+	// Pages still failing after every retry, one per line, to retry them later
+	if len(results.FailedURLs) > 0 {
+		var failed strings.Builder
+		for _, page := range results.FailedURLs {
+			failed.WriteString(page.URL + "\n")
+		}
+		err := os.WriteFile(filepath.Join(sessionDir, "failed_urls.txt"), []byte(failed.String()), 0644)
+		if err != nil {
+			return fmt.Errorf("error writing failed URLs file: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestResultsIsACopy(t *testing.T) {
+	ls, err := New(Config{BaseURL: "https://example.com/", IncludePatterns: []string{"/docs/"}, Reporter: NewConsoleReporter(io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls.recordLink("https://example.com/docs/", ClassifiedLink{Rel: []string{"nofollow"}})
+	ls.recordLink("https://other.example/", ClassifiedLink{})
+	ls.redirectedPages = append(ls.redirectedPages, RedirectedPage{
+		URL:       "https://example.com/old",
+		FinalURL:  "https://example.com/new",
+		Redirects: []Redirect{{URL: "https://example.com/old", StatusCode: 301}},
+	})
+	ls.linkStatuses = append(ls.linkStatuses, LinkStatus{URL: "https://example.com/docs/", StatusCode: 200})
+	ls.linkCheckStats = &LinkCheckStats{Checked: 1, Status2xx: 1}
+	ls.typeCheckStats = &ContentTypeStats{Checked: 2}
+	ls.downloadStats = &DownloadStats{Downloaded: 3}
+	ls.filterStats = FilterStats{Excluded: 4}
+
+	// A JSON snapshot shares nothing with the results it was made from
+	snapshot := func() string {
+		t.Helper()
+		results := ls.Results()
+		results.Statistics.ExecutionTime, results.Timestamp = "", ""
+		data, err := json.Marshal(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	before := snapshot()
+	tests := []struct {
+		name   string
+		mutate func(results *ScrapingResults)
+	}{
+		{"links", func(r *ScrapingResults) { r.AllLinks[0] = "changed" }},
+		{"internal links", func(r *ScrapingResults) { r.InternalLinks[0] = "changed" }},
+		{"external links", func(r *ScrapingResults) { r.ExternalLinks[0] = "changed" }},
+		{"classified links", func(r *ScrapingResults) { r.ClassifiedLinks[CategoryHTML][0].URL = "changed" }},
+		{"rel values", func(r *ScrapingResults) { r.ClassifiedLinks[CategoryHTML][0].Rel[0] = "changed" }},
+		{"category map", func(r *ScrapingResults) { delete(r.ClassifiedLinks, CategoryHTML) }},
+		{"redirect chains", func(r *ScrapingResults) { r.RedirectedPages[0].Redirects[0].StatusCode = 999 }},
+		{"checked links", func(r *ScrapingResults) { r.CheckedLinks[0].StatusCode = 999 }},
+		{"link check stats", func(r *ScrapingResults) { r.Statistics.LinkCheck.Checked = 999 }},
+		{"Content-Type stats", func(r *ScrapingResults) { r.Statistics.ContentTypes.Checked = 999 }},
+		{"download stats", func(r *ScrapingResults) { r.Statistics.Downloads.Downloaded = 999 }},
+		{"filter stats", func(r *ScrapingResults) { r.Statistics.Filters.Excluded = 999 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := ls.Results()
+			test.mutate(&results)
+			if snapshot() != before {
+				t.Errorf("changing the %s of the results changed the scraper", test.name)
+			}
+		})
+	}
+}
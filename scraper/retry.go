@@ -0,0 +1,181 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Longest Retry-After delay honored, longer ones are shortened to it
+const maxRetryAfter = 10 * time.Minute
+
+// RetryPolicy tells how requests failing with a transient error (network
+// error, timeout, 408, 429, 500, 502, 503, 504) are retried. The delay
+// doubles after each attempt, with a random part, unless the server gives a
+// Retry-After delay.
+type RetryPolicy struct {
+	MaxAttempts    int           // attempts per request, including the first one (default: 1, no retry)
+	InitialBackoff time.Duration // delay before the first retry (default: 1s)
+	MaxBackoff     time.Duration // longest delay between two attempts (default: 30s)
+}
+
+// StatusError is returned for HTTP responses with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // delay asked by the server with Retry-After, if any
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("HTTP status code: %d", e.StatusCode)
+}
+
+// FailedURL is a page that could not be loaded, even after retrying
+type FailedURL struct {
+	URL      string `json:"url"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// statusError builds the StatusError of a response
+func statusError(resp *http.Response) StatusError {
+	return StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header, given in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// retryable reports whether err is a transient failure worth another attempt:
+// a transient status code, a timeout of the client or a connection that
+// failed. Permanent errors (malformed URL, unsupported scheme, invalid TLS
+// certificate, redirect loop...) are not retried, nor are the requests whose
+// ctx was cancelled or ran out of time.
+func retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		// ctx is still alive: a request failing this way hit the client timeout
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	// The server closed or reset the connection, or refused it
+	for _, transient := range []error{io.EOF, io.ErrUnexpectedEOF, syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED, syscall.EPIPE, syscall.ETIMEDOUT} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	return p
+}
+
+// backoff returns the delay before the next attempt, after attempt failed with err
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var statusErr StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxRetryAfter)
+	}
+
+	delay := p.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	// Half fixed, half random, so that workers don't all retry at once
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// withRetry calls request until it succeeds, fails with a permanent error or
+// the attempts run out. It returns the number of attempts made and the last error.
+func (ls *LinkScraper) withRetry(ctx context.Context, link string, request func() error) (int, error) {
+	for attempt := 1; ; attempt++ {
+		ls.mutex.Lock()
+		ls.requests++
+		ls.mutex.Unlock()
+
+		err := request()
+		if err == nil || attempt >= ls.retry.MaxAttempts || !retryable(ctx, err) {
+			return attempt, err
+		}
+
+		delay := ls.retry.backoff(attempt, err)
+		ls.addRetry(link)
+		ls.logf("🔁 Retrying %s in %s (attempt %d/%d failed: %v)", link, delay.Round(time.Millisecond), attempt, ls.retry.MaxAttempts, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		}
+	}
+}
+
+func (ls *LinkScraper) addRetry(link string) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	ls.retries++
+	if !ls.retriedSet[link] {
+		ls.retriedSet[link] = true
+		ls.retriedURLs = append(ls.retriedURLs, link)
+	}
+}
+
+func (ls *LinkScraper) addFailed(link string, attempts int, err error) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	ls.failedURLs = append(ls.failedURLs, FailedURL{URL: link, Attempts: attempts, Error: err.Error()})
+}
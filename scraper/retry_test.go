@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error reporting a timeout, like the ones of net.Conn
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func requestError(err error) error {
+	return fmt.Errorf("error making request: %w", &url.Error{Op: "Get", URL: "https://example.com/", Err: err})
+}
+
+func dialError(errno syscall.Errno) error {
+	return requestError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)})
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"408", StatusError{StatusCode: http.StatusRequestTimeout}, true},
+		{"429", StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", StatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"502", StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"503", StatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"504", StatusError{StatusCode: http.StatusGatewayTimeout}, true},
+		{"404", StatusError{StatusCode: http.StatusNotFound}, false},
+		{"403", StatusError{StatusCode: http.StatusForbidden}, false},
+		{"501", StatusError{StatusCode: http.StatusNotImplemented}, false},
+		{"wrapped status", fmt.Errorf("page: %w", StatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"connection reset", dialError(syscall.ECONNRESET), true},
+		{"connection refused", dialError(syscall.ECONNREFUSED), true},
+		{"connection aborted", dialError(syscall.ECONNABORTED), true},
+		{"broken pipe", dialError(syscall.EPIPE), true},
+		{"network timeout", requestError(timeoutError{}), true},
+		{"client timeout", requestError(context.DeadlineExceeded), true},
+		{"server closed the connection", requestError(io.EOF), true},
+		{"unexpected EOF", requestError(io.ErrUnexpectedEOF), true},
+		{"temporary DNS failure", requestError(&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}), true},
+		{"unknown host", requestError(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}), false},
+		{"unsupported scheme", requestError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"invalid certificate", requestError(x509.UnknownAuthorityError{}), false},
+		{"redirect loop", errRedirectLoop{chain: []string{"https://example.com/a", "https://example.com/a"}}, false},
+		{"non-HTML", errNonHTML{contentType: "application/pdf"}, false},
+		{"cancelled", requestError(context.Canceled), false},
+		{"bare deadline", context.DeadlineExceeded, false},
+		{"other error", errors.New("error reading response"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := retryable(context.Background(), test.err); got != test.want {
+				t.Errorf("retryable(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryableDoneContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for _, ctx := range []context.Context{cancelled, expired} {
+		for _, err := range []error{
+			StatusError{StatusCode: http.StatusServiceUnavailable},
+			requestError(context.DeadlineExceeded),
+			dialError(syscall.ECONNRESET),
+		} {
+			if retryable(ctx, err) {
+				t.Errorf("retryable(%v) = true once ctx is done (%v)", err, ctx.Err())
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 120 * time.Second},
+		{"1", time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"1.5", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0}, // a date in the past
+	}
+	for _, test := range tests {
+		if got := parseRetryAfter(test.value); got != test.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+
+	// An HTTP date gives the delay until that date
+	date := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got <= 80*time.Second || got > 90*time.Second {
+		t.Errorf("parseRetryAfter(%q) = %v, want about 90s", date, got)
+	}
+}
@@ -0,0 +1,268 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsUserAgent is the product token looked up in robots.txt groups. The
+// requests carry a browser User-Agent (userAgent) that does not contain it: a
+// "User-agent: web-link-scraper" group is obeyed, but the website cannot tell
+// the requests of the scraper from those of a browser.
+const robotsUserAgent = "web-link-scraper"
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	regex   *regexp.Regexp
+}
+
+// robotsRules holds the directives of a robots.txt file that apply to us
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+	sitemaps   []string
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsEntry caches the rules of one host. They are fetched once, or again
+// after expires when robots.txt was unreachable.
+type robotsEntry struct {
+	mutex   sync.Mutex
+	done    bool
+	rules   *robotsRules
+	expires time.Time // zero when the rules are kept for the whole run
+}
+
+// unreachableRobots applies to the hosts whose robots.txt could not be
+// fetched (server error, network failure): RFC 9309 requires to assume a
+// complete disallow, the website may be overloaded
+var unreachableRobots = &robotsRules{rules: []robotsRule{newRobotsRule(false, "/")}}
+
+// errRobotsDisallowed is recorded for the links not requested because of robots.txt
+var errRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// How long an unreachable robots.txt keeps its host disallowed before it is fetched again
+const unreachableRobotsTTL = time.Minute
+
+// parseRobots reads a robots.txt file and keeps the group matching agent,
+// falling back to the "*" group when no specific group exists.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+	result := &robotsRules{}
+	groups := []*robotsGroup{}
+	var current *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share the same group
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// An empty Disallow means everything is allowed
+			if current != nil && value != "" {
+				current.rules = append(current.rules, newRobotsRule(key == "allow", value))
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			// Sitemap lines are global, they don't belong to any group
+			if value != "" {
+				result.sitemaps = append(result.sitemaps, value)
+			}
+		}
+		lastWasAgent = false
+	}
+
+	// Prefer the groups naming our user agent, otherwise use the wildcard ones
+	var specific, wildcard []*robotsGroup
+	for _, group := range groups {
+		for _, name := range group.agents {
+			if name == "*" {
+				wildcard = append(wildcard, group)
+				break
+			}
+			if token := productToken(name); token != "" && token == agent {
+				specific = append(specific, group)
+				break
+			}
+		}
+	}
+	selected := wildcard
+	if len(specific) > 0 {
+		selected = specific
+	}
+	for _, group := range selected {
+		result.rules = append(result.rules, group.rules...)
+		if group.crawlDelay > result.crawlDelay {
+			result.crawlDelay = group.crawlDelay
+		}
+	}
+
+	return result
+}
+
+// productToken returns the product token a User-agent line names: its
+// leading letters, underscores and hyphens (RFC 9309), so that
+// "web-link-scraper/1.0" names web-link-scraper
+func productToken(name string) string {
+	end := strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '-')
+	})
+	if end < 0 {
+		return name
+	}
+	return name[:end]
+}
+
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	// "*" matches any sequence of characters and a trailing "$" anchors the end of the path
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	return robotsRule{
+		allow:   allow,
+		pattern: pattern,
+		regex:   regexp.MustCompile("^" + expr),
+	}
+}
+
+// allowed reports whether the URL may be fetched.
+// The longest matching rule wins, and Allow wins ties.
+func (r *robotsRules) allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	matchLen := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !rule.regex.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > matchLen || (len(rule.pattern) == matchLen && rule.allow) {
+			matchLen = len(rule.pattern)
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsFor returns the robots.txt rules of the URL's host, fetching them on
+// first use with the retry policy of the requests. A missing robots.txt (4xx
+// status) allows everything, an unreachable one (5xx status, network error)
+// disallows everything for unreachableRobotsTTL, then it is fetched again.
+// When ctx is cancelled during the fetch, nothing is cached and nil is returned.
+func (ls *LinkScraper) robotsFor(ctx context.Context, u *url.URL) *robotsRules {
+	key := u.Scheme + "://" + u.Host
+
+	ls.robotsMutex.Lock()
+	entry, exists := ls.robots[key]
+	if !exists {
+		entry = &robotsEntry{}
+		ls.robots[key] = entry
+	}
+	ls.robotsMutex.Unlock()
+
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	if entry.done && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		return entry.rules
+	}
+	robotsURL := key + "/robots.txt"
+	var rules *robotsRules
+	_, err := ls.withRetry(ctx, robotsURL, func() error {
+		var err error
+		rules, err = ls.fetchRobots(ctx, robotsURL)
+		return err
+	})
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+	entry.expires = time.Time{}
+	if err != nil {
+		ls.logf("⚠️  Could not read %s, host disallowed for %s: %v", robotsURL, unreachableRobotsTTL, err)
+		entry.expires = time.Now().Add(unreachableRobotsTTL)
+	}
+	entry.rules = rules
+	entry.done = true
+	return entry.rules
+}
+
+func (ls *LinkScraper) fetchRobots(ctx context.Context, robotsURL string) (*robotsRules, error) {
+	req, err := ls.newRequest(ctx, "GET", robotsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return unreachableRobots, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return unreachableRobots, statusError(resp)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		// No robots.txt, or one we may not read: no restriction
+		return nil, nil
+	}
+
+	// Limit the size read, as recommended by RFC 9309
+	return parseRobots(io.LimitReader(resp.Body, 500*1024), robotsUserAgent), nil
+}
+
+func (ls *LinkScraper) addDisallowed(link string) {
+	ls.mutex.Lock()
+	ls.disallowedURLs = append(ls.disallowedURLs, link)
+	ls.mutex.Unlock()
+	ls.logf("🤖 Disallowed by robots.txt: %s", link)
+}
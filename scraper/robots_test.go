@@ -0,0 +1,251 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"no rules", "", "/page", true},
+		{"disallow all", "User-agent: *\nDisallow: /", "/page", false},
+		{"empty disallow", "User-agent: *\nDisallow:", "/page", true},
+		{"prefix match", "User-agent: *\nDisallow: /private", "/private/page", false},
+		{"prefix mismatch", "User-agent: *\nDisallow: /private", "/public", true},
+		{"prefix without slash", "User-agent: *\nDisallow: /private", "/private-notes", false},
+		{"case-sensitive path", "User-agent: *\nDisallow: /Private", "/private", true},
+		{"wildcard", "User-agent: *\nDisallow: /*.pdf", "/docs/file.pdf", false},
+		{"wildcard in the middle", "User-agent: *\nDisallow: /a/*/b", "/a/x/y/b", false},
+		{"end anchor", "User-agent: *\nDisallow: /*.php$", "/index.php", false},
+		{"end anchor with query", "User-agent: *\nDisallow: /*.php$", "/index.php?id=1", true},
+		{"query string", "User-agent: *\nDisallow: /*?sort=", "/list?sort=asc", false},
+		{"longest match allow", "User-agent: *\nDisallow: /folder\nAllow: /folder/page", "/folder/page", true},
+		{"longest match disallow", "User-agent: *\nAllow: /folder\nDisallow: /folder/page", "/folder/page", false},
+		{"allow wins ties", "User-agent: *\nDisallow: /page\nAllow: /page", "/page", true},
+		{"allow wins ties in any order", "User-agent: *\nAllow: /page\nDisallow: /page", "/page", true},
+		{"wildcard length counts", "User-agent: *\nAllow: /*page\nDisallow: /page", "/page", true},
+		{"comments", "User-agent: * # all robots\nDisallow: /private # not this\n# Disallow: /", "/public", true},
+		{"case-insensitive keys", "USER-AGENT: *\nDISALLOW: /private", "/private", false},
+		{"rules before any group", "Disallow: /\nUser-agent: *\nDisallow: /private", "/public", true},
+		{"root path", "User-agent: *\nDisallow: /$", "/", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(test.robots), robotsUserAgent)
+			u, err := url.Parse("https://example.com" + test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rules.allowed(u); got != test.want {
+				t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"specific group wins", "User-agent: *\nDisallow: /\n\nUser-agent: web-link-scraper\nDisallow: /private", "/page", true},
+		{"specific group applies", "User-agent: *\nDisallow:\n\nUser-agent: web-link-scraper\nDisallow: /private", "/private", false},
+		{"agent case-insensitive", "User-agent: Web-Link-Scraper\nDisallow: /private", "/private", false},
+		{"other agent ignored", "User-agent: googlebot\nDisallow: /", "/page", true},
+		{"other agent with wildcard", "User-agent: googlebot\nDisallow: /\n\nUser-agent: *\nDisallow: /private", "/page", true},
+		{"consecutive agents share a group", "User-agent: googlebot\nUser-agent: web-link-scraper\nDisallow: /private", "/private", false},
+		{"groups merged", "User-agent: web-link-scraper\nDisallow: /a\n\nUser-agent: web-link-scraper\nDisallow: /b", "/b", false},
+		{"wildcard groups merged", "User-agent: *\nDisallow: /a\n\nUser-agent: *\nDisallow: /b", "/b", false},
+		{"agent with version", "User-agent: *\nDisallow: /\n\nUser-agent: web-link-scraper/1.0\nDisallow: /private", "/page", true},
+		{"empty agent ignored", "User-agent: *\nDisallow: /private\n\nUser-agent:\nDisallow:", "/private", false},
+		{"empty agent alone ignored", "User-agent:\nDisallow: /", "/page", true},
+		{"part of our token ignored", "User-agent: *\nDisallow: /private\n\nUser-agent: web\nDisallow:", "/private", false},
+		{"other part of our token ignored", "User-agent: *\nDisallow: /private\n\nUser-agent: scraper\nDisallow:", "/private", false},
+		{"longer token ignored", "User-agent: web-link-scraper-pro\nDisallow: /", "/page", true},
+		{"token case-insensitive", "User-agent: *\nDisallow:\n\nUser-agent: WEB-LINK-SCRAPER\nDisallow: /private", "/private", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(test.robots), robotsUserAgent)
+			u, _ := url.Parse("https://example.com" + test.path)
+			if got := rules.allowed(u); got != test.want {
+				t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRobotsDirectives(t *testing.T) {
+	robots := `Sitemap: https://example.com/sitemap.xml
+User-agent: googlebot
+Crawl-delay: 10
+
+User-agent: *
+Crawl-delay: 2.5
+Disallow: /private
+
+Sitemap: https://example.com/news.xml
+`
+	rules := parseRobots(strings.NewReader(robots), robotsUserAgent)
+	if rules.crawlDelay != 2500*time.Millisecond {
+		t.Errorf("crawlDelay = %v, want 2.5s", rules.crawlDelay)
+	}
+	want := []string{"https://example.com/sitemap.xml", "https://example.com/news.xml"}
+	if !slices.Equal(rules.sitemaps, want) {
+		t.Errorf("sitemaps = %q, want %q", rules.sitemaps, want)
+	}
+}
+
+func TestRobotsFetchStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusOK, "User-agent: *\nDisallow: /private", true},
+		{http.StatusNotFound, "", true},
+		{http.StatusForbidden, "", true},
+		{http.StatusInternalServerError, "", false},
+		{http.StatusServiceUnavailable, "", false},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			io.WriteString(w, test.body)
+		}))
+		ls, err := New(Config{BaseURL: server.URL, Reporter: NewConsoleReporter(io.Discard)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(server.URL + "/page")
+		if got := ls.robotsFor(context.Background(), u).allowed(u); got != test.want {
+			t.Errorf("robots.txt with status %d: allowed = %v, want %v", test.status, got, test.want)
+		}
+		server.Close()
+	}
+}
+
+func TestRobotsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+
+	ls, err := New(Config{BaseURL: serverURL, Reporter: NewConsoleReporter(io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(serverURL + "/page")
+	if ls.robotsFor(context.Background(), u).allowed(u) {
+		t.Errorf("unreachable robots.txt allows %s", u)
+	}
+}
+
+func TestRobotsCancelledNotCached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "User-agent: *\nDisallow: /private")
+	}))
+	defer server.Close()
+
+	ls, err := New(Config{BaseURL: server.URL, Reporter: NewConsoleReporter(io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(server.URL + "/private")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if rules := ls.robotsFor(ctx, u); rules != nil {
+		t.Fatalf("cancelled fetch returned rules")
+	}
+	if ls.robotsFor(context.Background(), u).allowed(u) {
+		t.Errorf("robots.txt not fetched again after a cancelled fetch")
+	}
+}
+
+// flakyRobots serves robots.txt with a 503 status for the first failures requests
+func flakyRobots(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "User-agent: *\nDisallow: /private")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRobotsRetried(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int32
+		maxAttempts int
+		want        bool
+		requests    int32
+	}{
+		{"no retry", 1, 1, false, 1},
+		{"retried until served", 2, 3, true, 3},
+		{"still unreachable after the last attempt", 3, 3, false, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := flakyRobots(t, test.failures)
+			ls, err := New(Config{
+				BaseURL:  server.URL,
+				Retry:    RetryPolicy{MaxAttempts: test.maxAttempts, InitialBackoff: time.Millisecond},
+				Reporter: NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, _ := url.Parse(server.URL + "/page")
+			if got := ls.robotsFor(context.Background(), u).allowed(u); got != test.want {
+				t.Errorf("allowed = %v, want %v", got, test.want)
+			}
+			if got := requests.Load(); got != test.requests {
+				t.Errorf("robots.txt requested %d times, want %d", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestRobotsUnreachableExpires(t *testing.T) {
+	server, requests := flakyRobots(t, 1)
+	ls, err := New(Config{BaseURL: server.URL, Reporter: NewConsoleReporter(io.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(server.URL + "/page")
+	if ls.robotsFor(context.Background(), u).allowed(u) {
+		t.Fatal("unreachable robots.txt allows the host")
+	}
+	// Cached until it expires
+	if ls.robotsFor(context.Background(), u).allowed(u) || requests.Load() != 1 {
+		t.Fatalf("unreachable robots.txt fetched again before expiring (%d requests)", requests.Load())
+	}
+
+	ls.robots[server.URL].expires = time.Now().Add(-time.Second)
+	if !ls.robotsFor(context.Background(), u).allowed(u) {
+		t.Error("robots.txt not fetched again once expired")
+	}
+	// Rules that were read are kept for the rest of the run
+	if entry := ls.robots[server.URL]; !entry.expires.IsZero() {
+		t.Errorf("rules read successfully expire at %v", entry.expires)
+	}
+}
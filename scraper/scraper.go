@@ -0,0 +1,940 @@
+// Package scraper crawls websites and collects, classifies and saves their links.
+//
+// A crawl is configured with a Config, started with Scrape and its outcome is
+// read with Results:
+//
+//	ls, err := scraper.New(scraper.Config{BaseURL: "https://example.com", MaxDepth: 2})
+//	if err != nil {
+//		return err
+//	}
+//	ls.Scrape(ctx)
+//	results := ls.Results()
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LinkScraper crawls a website and collects, classifies and saves its links
+type LinkScraper struct {
+	baseURL         *url.URL
+	client          *http.Client
+	fetcher         Fetcher
+	reporter        Reporter
+	proxies         *proxyPool
+	headers         map[string]string
+	headerHosts     hostScope
+	visitedURL      map[string]bool // canonical forms of the queued and scraped pages
+	links           []string
+	linkKeys        map[string]string // canonical form -> recorded link
+	canonicalRules  []CanonicalRule
+	collapsedSet    map[string]bool
+	collapsedLinks  []CollapsedLink
+	internalLinks   []string
+	externalLinks   []string
+	classifiedLinks map[LinkCategory][]ClassifiedLink // Nouvelle structure pour la classification
+	errors          []string
+	disallowedURLs  []string
+	scrapedPages    map[string]string // final (or canonical) URL -> crawled URL it was scraped as
+	redirectedPages []RedirectedPage
+	duplicatePages  []DuplicatePage
+	redirectLoops   int
+	canonicalDedup  bool
+	retry           RetryPolicy
+	retries         int // retry attempts made
+	retriedSet      map[string]bool
+	retriedURLs     []string
+	failedURLs      []FailedURL
+	sitemaps        []string // sitemaps read to seed the crawl
+	sitemapLinks    int      // links first discovered in a sitemap
+	linkStatuses    []LinkStatus
+	linkCheckStats  *LinkCheckStats
+	contentTypes    *contentTypeCache
+	typeCheckStats  *ContentTypeStats
+	downloadStats   *DownloadStats
+	filter          *urlFilter
+	filterRecorded  bool
+	filteredURLs    map[string]bool
+	filterStats     FilterStats
+	mutex           sync.RWMutex
+	maxDepth        int
+	maxPages        int
+	currentDepth    int
+	pagesVisited    int
+	pagesCrawled    int // pages done, never decreases unlike pagesVisited, for the metrics
+	resumedPages    int // pages visited before the crawl was resumed
+	requests        int // requests made by this run
+	workers         int
+	frontier        *frontier
+	pageLimitOnce   sync.Once
+	resumed         bool
+	stateFile       string
+	stateMutex      sync.Mutex
+	checkpointEvery time.Duration
+	ignoreRobots    bool
+	skipNofollow    bool
+	useSitemap      bool
+	robots          map[string]*robotsEntry
+	limiter         *rateLimiter
+	robotsMutex     sync.Mutex
+	startTime       time.Time
+	outputDir       string
+	outputFormats   []OutputFormat
+	reports         []ReportFormat
+	sessionDir      string
+	sessionMutex    sync.Mutex
+}
+
+// Config holds the settings of a LinkScraper
+type Config struct {
+	BaseURL   string // website to scrape, the crawl starts from this page
+	MaxDepth  int
+	MaxPages  int    // stop the crawl after scraping this many pages (0 = unlimited)
+	OutputDir string // where results and state are saved (empty = nothing saved)
+	Workers   int    // number of pages scraped concurrently (default: 1)
+
+	// OutputFormats selects the files written by SaveResults (default: json)
+	OutputFormats []OutputFormat
+
+	// Reports adds human readable reports to the files written by SaveResults
+	Reports []ReportFormat
+
+	// Client is used for every request. When nil, a client with the given
+	// Timeout (default: 15s) that accepts invalid TLS certificates is created.
+	Client  *http.Client
+	Timeout time.Duration
+
+	// Retry controls how requests failing with a transient error are retried
+	Retry RetryPolicy
+
+	// Proxies routes the requests of the default client through these proxies
+	// (http://, https:// or socks5:// URLs), used in turn. A proxy failing
+	// repeatedly is left aside for a while. Ignored when Client is set.
+	Proxies []string
+
+	// Fetcher loads the crawled pages (default: a plain HTTP GET with Client).
+	// Set it to a RenderFetcher for sites whose content is built with JavaScript.
+	// robots.txt, sitemaps, link checks and downloads always use Client.
+	Fetcher Fetcher
+
+	// Headers are added to the requests made to HeaderHosts (default: the
+	// host of BaseURL), overriding the default ones. They are left out of
+	// the requests to other hosts, redirects included, so that tokens are
+	// not sent to the external links.
+	Headers     map[string]string
+	HeaderHosts []string
+
+	// Jar keeps the cookies of the default client (default: a new NewCookieJar),
+	// so that the session cookies set by the website are sent back. Cookies are
+	// added to the jar of the client, e.g. the ones read by LoadCookieFile; a
+	// Domain starting with a dot includes the subdomains. BasicAuth credentials
+	// are sent to the host of BaseURL. Jar and BasicAuth are ignored when Client is set.
+	// The browser of a RenderFetcher keeps its own cookies: New refuses Cookies
+	// and BasicAuth with it.
+	Jar       http.CookieJar
+	Cookies   []*http.Cookie
+	BasicAuth *BasicAuth
+
+	// Reporter receives the events of the run: pages, links, errors...
+	// (default: a ConsoleReporter printing to the standard output)
+	Reporter Reporter
+
+	// Metrics records the status codes and latency of the requests of the
+	// default client, see Batch.MonitorHandler. Ignored when Client is set.
+	Metrics *Metrics
+
+	IgnoreRobots bool // don't fetch nor respect robots.txt
+	SkipNofollow bool // don't follow rel=nofollow links (nor the links of nofollow pages)
+
+	// CanonicalRules decide which URLs are the same link, recorded and crawled
+	// once (default: DefaultCanonicalRules). See ParseCanonicalRules. The
+	// predefined rules are applied in a fixed order, whatever their order
+	// here, followed by the others.
+	CanonicalRules []CanonicalRule
+
+	// CanonicalDedup skips the pages whose <link rel="canonical"> URL was
+	// already scraped. Pages are always deduplicated by their URL after redirects.
+	CanonicalDedup bool
+	UseSitemap     bool // seed the crawl with the URLs of the site's XML sitemaps
+
+	// Request pacing, in requests per second (0 = unlimited)
+	RateLimit       float64 // per host
+	GlobalRateLimit float64 // over all hosts
+	RateJitter      float64 // random extra delay, as a fraction of the per-host interval
+
+	// URL filters. Patterns are regular expressions matched against the full
+	// URL, prefixes are matched against its path. Excluding wins over including.
+	// Filtered URLs are not followed; with FilterRecorded they are not recorded either.
+	IncludePatterns []string
+	ExcludePatterns []string
+	PathPrefixes    []string
+	FilterRecorded  bool
+
+	// Crawl state persistence, used to resume interrupted crawls
+	StateFile          string        // default: <OutputDir>/state.json
+	CheckpointInterval time.Duration // default: 30s
+	Resume             *State        // state loaded with LoadState to continue from
+}
+
+// User-Agent sent with every request
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// New creates a scraper from the given configuration
+func New(config Config) (*LinkScraper, error) {
+	parsedURL, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	reporter := config.Reporter
+	if reporter == nil {
+		reporter = NewConsoleReporter(os.Stdout)
+	}
+
+	var proxies *proxyPool
+	client := config.Client
+	if client == nil {
+		tr := &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+			MaxIdleConnsPerHost: workers,
+		}
+
+		var transport http.RoundTripper = tr
+		if len(config.Proxies) > 0 {
+			proxies, err = newProxyPool(config.Proxies, reporter)
+			if err != nil {
+				return nil, err
+			}
+			tr.Proxy = proxyFromContext
+			transport = &proxyTransport{base: tr, pool: proxies}
+		}
+
+		if config.BasicAuth != nil {
+			transport = newAuthTransport(transport, *config.BasicAuth, parsedURL)
+		}
+		if config.Metrics != nil {
+			transport = &metricsTransport{base: transport, metrics: config.Metrics}
+		}
+
+		jar := config.Jar
+		if jar == nil {
+			jar = NewCookieJar()
+		}
+
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 15 * time.Second
+		}
+		client = &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			Jar:       jar,
+		}
+	}
+	if len(config.Cookies) > 0 {
+		if client.Jar == nil {
+			return nil, fmt.Errorf("cookies need a client with a cookie jar")
+		}
+		addCookies(client.Jar, config.Cookies)
+	}
+
+	headerHosts := newHostScope(config.HeaderHosts, parsedURL)
+	if len(config.Headers) > 0 {
+		// The client copies the headers of a request to its redirects
+		scoped := *client
+		scoped.CheckRedirect = headerHosts.stripHeaders(config.Headers, client.CheckRedirect)
+		client = &scoped
+	}
+
+	fetcher := config.Fetcher
+	if fetcher == nil {
+		fetcher = newHTTPFetcher(client, config.Headers, headerHosts)
+	}
+	if _, ok := fetcher.(*RenderFetcher); ok && (len(config.Cookies) > 0 || config.BasicAuth != nil) {
+		return nil, fmt.Errorf("the headless browser can't send cookies or basic auth credentials")
+	}
+
+	stateFile := config.StateFile
+	if stateFile == "" {
+		stateFile = defaultStateFile(config.OutputDir)
+	}
+	checkpointEvery := config.CheckpointInterval
+	if checkpointEvery <= 0 {
+		checkpointEvery = 30 * time.Second
+	}
+
+	outputFormats := config.OutputFormats
+	if len(outputFormats) == 0 {
+		outputFormats = []OutputFormat{FormatJSON}
+	}
+	for _, format := range outputFormats {
+		if !format.valid() {
+			return nil, fmt.Errorf("unknown output format: %q", format)
+		}
+	}
+
+	for _, format := range config.Reports {
+		if !format.valid() {
+			return nil, fmt.Errorf("unknown report format: %q", format)
+		}
+	}
+
+	canonicalRules := config.CanonicalRules
+	if canonicalRules == nil {
+		canonicalRules = DefaultCanonicalRules
+	}
+	canonicalRules = orderCanonicalRules(canonicalRules)
+
+	filter, err := newURLFilter(config.IncludePatterns, config.ExcludePatterns, config.PathPrefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.OutputDir != "" {
+		err := os.MkdirAll(config.OutputDir, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %v", err)
+		}
+	}
+
+	// Initialisation de la map pour les liens classifiés
+	classifiedLinks := make(map[LinkCategory][]ClassifiedLink)
+	for category := range fileExtensions {
+		classifiedLinks[category] = make([]ClassifiedLink, 0)
+	}
+	classifiedLinks[CategoryOther] = make([]ClassifiedLink, 0)
+
+	ls := &LinkScraper{
+		baseURL:         parsedURL,
+		client:          client,
+		fetcher:         fetcher,
+		reporter:        reporter,
+		proxies:         proxies,
+		headers:         config.Headers,
+		headerHosts:     headerHosts,
+		visitedURL:      make(map[string]bool),
+		links:           make([]string, 0),
+		linkKeys:        make(map[string]string),
+		canonicalRules:  canonicalRules,
+		collapsedSet:    make(map[string]bool),
+		collapsedLinks:  make([]CollapsedLink, 0),
+		internalLinks:   make([]string, 0),
+		externalLinks:   make([]string, 0),
+		classifiedLinks: classifiedLinks,
+		errors:          make([]string, 0),
+		disallowedURLs:  make([]string, 0),
+		scrapedPages:    make(map[string]string),
+		redirectedPages: make([]RedirectedPage, 0),
+		duplicatePages:  make([]DuplicatePage, 0),
+		canonicalDedup:  config.CanonicalDedup,
+		retry:           config.Retry.withDefaults(),
+		retriedSet:      make(map[string]bool),
+		retriedURLs:     make([]string, 0),
+		failedURLs:      make([]FailedURL, 0),
+		filter:          filter,
+		filterRecorded:  config.FilterRecorded,
+		filteredURLs:    make(map[string]bool),
+		maxDepth:        config.MaxDepth,
+		maxPages:        config.MaxPages,
+		currentDepth:    0,
+		workers:         workers,
+		frontier:        newFrontier(),
+		stateFile:       stateFile,
+		checkpointEvery: checkpointEvery,
+		ignoreRobots:    config.IgnoreRobots,
+		skipNofollow:    config.SkipNofollow,
+		useSitemap:      config.UseSitemap,
+		robots:          make(map[string]*robotsEntry),
+		limiter:         newRateLimiter(config.RateLimit, config.GlobalRateLimit, config.RateJitter),
+		contentTypes:    newContentTypeCache(),
+		startTime:       time.Now(),
+		outputDir:       config.OutputDir,
+		outputFormats:   outputFormats,
+		reports:         config.Reports,
+	}
+
+	// The redirects of a page are paced and checked against robots.txt like the page
+	if f, ok := fetcher.(*httpFetcher); ok {
+		f.beforeRedirect = ls.politeWait
+	}
+
+	if config.Resume != nil {
+		if err := ls.restoreState(config.Resume); err != nil {
+			return nil, err
+		}
+	}
+	return ls, nil
+}
+
+// addLink records a link, along with where it was found (origin's source
+// page, depth, element...), and reports whether it was new
+func (ls *LinkScraper) addLink(link string, origin ClassifiedLink) bool {
+	classifiedLink, added := ls.recordLink(link, origin)
+	if added {
+		ls.report(Event{Type: EventLinkFound, URL: link, Depth: classifiedLink.Depth, Link: &classifiedLink})
+	}
+	return added
+}
+
+func (ls *LinkScraper) recordLink(link string, origin ClassifiedLink) (ClassifiedLink, bool) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	if ls.filterRecorded && !ls.passesFilters(link) {
+		return ClassifiedLink{}, false
+	}
+
+	// Éviter les doublons, y compris les variantes d'une même URL
+	key := ls.canonicalKey(link)
+	if recorded, exists := ls.linkKeys[key]; exists {
+		if recorded != link && !ls.collapsedSet[link] {
+			ls.collapsedSet[link] = true
+			ls.collapsedLinks = append(ls.collapsedLinks, CollapsedLink{URL: link, SameAs: recorded})
+		}
+		return ClassifiedLink{}, false
+	}
+
+	ls.linkKeys[key] = link
+	ls.links = append(ls.links, link)
+
+	// Classifier le lien
+	classifiedLink := origin
+	classifiedLink.URL = link
+	classifiedLink.Category, classifiedLink.FileType = ClassifyLink(link)
+	ls.classifiedLinks[classifiedLink.Category] = append(ls.classifiedLinks[classifiedLink.Category], classifiedLink)
+
+	// Catégoriser comme interne ou externe
+	if ls.isInternalLink(link) {
+		ls.internalLinks = append(ls.internalLinks, link)
+	} else {
+		ls.externalLinks = append(ls.externalLinks, link)
+	}
+	return classifiedLink, true
+}
+
+// addError records an error, about link when it concerns a given URL
+func (ls *LinkScraper) addError(link, err string) {
+	ls.mutex.Lock()
+	ls.errors = append(ls.errors, fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), err))
+	ls.mutex.Unlock()
+	ls.report(Event{Type: EventError, URL: link, Message: err})
+}
+
+// Scrape crawls the website starting from the base URL. Pages are taken from a
+// shared frontier by a pool of workers, so the crawl is breadth-first and
+// does not rely on recursion, however deep or broad the site is.
+// When a previous state was restored, the crawl continues from its frontier instead.
+// With UseSitemap, the URLs listed in the site's sitemaps are added as seeds too.
+// The state is saved periodically and once more when the crawl ends.
+//
+// Cancelling ctx stops the crawl: requests in flight are aborted and their
+// pages stay in the frontier with the queued ones, so they are part of the saved
+// state. The same happens once MaxPages pages were scraped.
+// The results collected so far remain available and ctx.Err() is returned.
+func (ls *LinkScraper) Scrape(ctx context.Context) error {
+	stopWatching := context.AfterFunc(ctx, ls.frontier.close)
+	defer stopWatching()
+
+	if !ls.resumed {
+		ls.enqueue(ls.baseURL.String(), 0)
+		if ls.useSitemap {
+			ls.seedFromSitemaps(ctx)
+		}
+	}
+
+	stop := make(chan struct{})
+	if ls.stateFile != "" {
+		go ls.checkpoint(ls.checkpointEvery, stop)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < ls.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ls.worker(ctx)
+		}()
+	}
+	wg.Wait()
+	close(stop)
+
+	if err := ls.SaveState(); err != nil {
+		ls.addError("", fmt.Sprintf("Error saving state: %v", err))
+	}
+	return ctx.Err()
+}
+
+func (ls *LinkScraper) worker(ctx context.Context) {
+	for {
+		task, ok := ls.frontier.pop()
+		if !ok {
+			return
+		}
+		// Interrupted tasks are not marked as done: the frontier is closed
+		// by then and they remain part of its snapshot
+		if ls.visit(ctx, task) {
+			ls.frontier.done(task)
+		}
+	}
+}
+
+// enqueue schedules a page unless it lies beyond maxDepth, is rejected by the
+// URL filters (the base URL never is) or was already seen.
+// Pages are marked as visited when queued so that two workers never scrape the same URL.
+func (ls *LinkScraper) enqueue(link string, depth int) {
+	if depth > ls.maxDepth {
+		return
+	}
+
+	// The push happens under the lock so that a state snapshot never
+	// sees a page marked as visited without it being in the frontier
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	key := ls.canonicalKey(link)
+	if ls.visitedURL[key] {
+		return
+	}
+	if link != ls.baseURL.String() && !ls.passesFilters(link) {
+		return
+	}
+	ls.visitedURL[key] = true
+	ls.frontier.push(CrawlTask{URL: link, Depth: depth})
+}
+
+// visit scrapes one page and queues its links. It returns false when the page
+// was not handled because the crawl is stopping, so that it can be resumed later.
+func (ls *LinkScraper) visit(ctx context.Context, task CrawlTask) bool {
+	parsedURL, err := url.Parse(task.URL)
+	if err != nil {
+		ls.addError(task.URL, fmt.Sprintf("Error on %s: %v", task.URL, err))
+		return true
+	}
+
+	allowed, err := ls.politeWait(ctx, parsedURL)
+	if err != nil {
+		return false
+	}
+	if !allowed {
+		ls.addDisallowed(task.URL)
+		return true
+	}
+
+	// The page is counted before being scraped so that workers never go over maxPages
+	ls.mutex.Lock()
+	if ls.maxPages > 0 && ls.pagesVisited >= ls.maxPages {
+		ls.mutex.Unlock()
+		ls.stopAtPageLimit()
+		return false
+	}
+	ls.pagesVisited++
+	if task.Depth > ls.currentDepth {
+		ls.currentDepth = task.Depth
+	}
+	ls.mutex.Unlock()
+
+	ls.report(Event{Type: EventPageStart, URL: task.URL, Depth: task.Depth})
+
+	newInternalLinks, err := ls.scrapePage(ctx, task.URL, task.Depth)
+	if err != nil && ctx.Err() != nil {
+		// Aborted by the cancellation, this is not an error of the page
+		ls.mutex.Lock()
+		ls.pagesVisited--
+		ls.mutex.Unlock()
+		return false
+	}
+	ls.mutex.Lock()
+	ls.pagesCrawled++
+	ls.mutex.Unlock()
+
+	if err != nil {
+		var loop errRedirectLoop
+		if errors.As(err, &loop) {
+			ls.mutex.Lock()
+			ls.redirectLoops++
+			ls.mutex.Unlock()
+		}
+		var disallowed errDisallowedRedirect
+		if errors.As(err, &disallowed) {
+			ls.addDisallowed(disallowed.url)
+			return true
+		}
+		ls.addError(task.URL, fmt.Sprintf("Error on %s: %v", task.URL, err))
+		return true
+	}
+
+	if task.Depth < ls.maxDepth {
+		for _, link := range newInternalLinks {
+			ls.enqueue(link, task.Depth+1)
+		}
+	}
+	return true
+}
+
+// stopAtPageLimit closes the frontier once maxPages pages were scraped
+func (ls *LinkScraper) stopAtPageLimit() {
+	ls.pageLimitOnce.Do(func() {
+		ls.logf("🛑 Page limit reached (%d pages), stopping crawl", ls.maxPages)
+		ls.frontier.close()
+	})
+}
+
+// newRequest creates a request carrying our User-Agent, and the configured
+// headers when link is on one of their hosts
+func (ls *LinkScraper) newRequest(ctx context.Context, method, link string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	ls.headerHosts.setHeaders(req, ls.headers)
+	return req, nil
+}
+
+// TestConnection sends a HEAD request to the base URL with the client of the
+// crawl (proxies, headers, cookies, authentication) and returns its status code.
+// The request gives up after the client timeout, 15s when there is none.
+func (ls *LinkScraper) TestConnection(ctx context.Context) (int, error) {
+	timeout := ls.client.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	allowed, err := ls.politeWait(ctx, ls.baseURL)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, fmt.Errorf("disallowed by robots.txt")
+	}
+	req, err := ls.newRequest(ctx, http.MethodHead, ls.baseURL.String())
+	if err != nil {
+		return 0, err
+	}
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// politeWait checks robots.txt and waits for the rate limiter before a request.
+// It returns false when robots.txt disallows the URL, and an error when ctx
+// was cancelled while waiting.
+func (ls *LinkScraper) politeWait(ctx context.Context, u *url.URL) (bool, error) {
+	var crawlDelay time.Duration
+	if !ls.ignoreRobots {
+		rules := ls.robotsFor(ctx, u)
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if !rules.allowed(u) {
+			return false, nil
+		}
+		if rules != nil {
+			crawlDelay = rules.crawlDelay
+		}
+	}
+	if err := ls.limiter.wait(ctx, u.Host, crawlDelay); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// linkWait paces the request of a discovered link: internal links go through
+// politeWait, external ones only wait for the rate limiter
+func (ls *LinkScraper) linkWait(ctx context.Context, u *url.URL) (bool, error) {
+	if ls.isInternalLink(u.String()) {
+		return ls.politeWait(ctx, u)
+	}
+	if err := ls.limiter.wait(ctx, u.Host, 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ls *LinkScraper) scrapePage(ctx context.Context, targetURL string, depth int) ([]string, error) {
+	var page *Page
+	attempts, err := ls.withRetry(ctx, targetURL, func() error {
+		var err error
+		page, err = ls.fetcher.Fetch(ctx, targetURL)
+		return err
+	})
+	if err != nil {
+		if retryable(ctx, err) {
+			ls.addFailed(targetURL, attempts, err)
+		}
+		// The response already tells what the document is, no need to ask again
+		var nonHTML errNonHTML
+		if errors.As(err, &nonHTML) {
+			ls.contentTypes.store(targetURL, nonHTML.contentType, nonHTML.disposition)
+		}
+		return nil, err
+	}
+
+	if page.URL != targetURL {
+		ls.addRedirect(targetURL, page)
+		ls.logf("↪️  Redirected: %s → %s", targetURL, page.URL)
+		if !ls.isInternalLink(page.URL) {
+			return nil, nil
+		}
+	}
+	// Several URLs can lead to the same page, scrape it only once
+	if owner, ok := ls.claimPage(page.URL, targetURL); !ok {
+		if owner != targetURL {
+			ls.addDuplicate(targetURL, owner)
+		}
+		return nil, nil
+	}
+
+	// Parse HTML
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.HTML))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %v", err)
+	}
+
+	if ls.canonicalDedup {
+		canonical := ls.canonicalURL(doc, page.URL)
+		if canonical != "" && canonical != page.URL {
+			if owner, ok := ls.claimPage(canonical, targetURL); !ok {
+				ls.addDuplicate(targetURL, owner)
+				return nil, nil
+			}
+		}
+	}
+
+	newInternalLinks, linkCount := ls.extractLinks(doc, targetURL, page.URL, depth)
+	ls.report(Event{Type: EventPageDone, URL: targetURL, Depth: depth, Links: linkCount})
+	return newInternalLinks, nil
+}
+
+// extractLinks records the links of a parsed page and returns the internal
+// HTML pages to follow, with the number of links found. Relative links are resolved against baseURL, the URL
+// the document was finally loaded from.
+func (ls *LinkScraper) extractLinks(doc *goquery.Document, sourcePage, baseURL string, depth int) ([]string, int) {
+	origin := linkOrigin{
+		sourcePage: sourcePage,
+		depth:      depth,
+		nofollow:   pageNofollow(doc),
+	}
+
+	// Extract all links
+	linkCount := 0
+	newInternalLinks := []string{}
+
+	// addResource records a link to an asset of the page, which is not crawled
+	addResource := func(s *goquery.Selection, ref string) {
+		cleanURL := NormalizeURL(ref, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	}
+
+	// Extract <a href=""> links
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		// Clean and normalize URL
+		cleanURL := NormalizeURL(href, baseURL)
+		if cleanURL != "" {
+			link := origin.from(s)
+			ls.addLink(cleanURL, link)
+			linkCount++
+
+			// Only add HTML pages to internal links for recursive scraping
+			category, _ := ClassifyLink(cleanURL)
+			if ls.isInternalLink(cleanURL) && category == CategoryHTML && ls.follows(link) {
+				newInternalLinks = append(newInternalLinks, cleanURL)
+			}
+		}
+	})
+
+	// Extract <link> elements
+	doc.Find("link[href]").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		rel, _ := s.Attr("rel")
+		// Only keep certain types of links
+		if strings.Contains(rel, "canonical") || strings.Contains(rel, "alternate") {
+			cleanURL := NormalizeURL(href, baseURL)
+			if cleanURL != "" {
+				link := origin.from(s)
+				ls.addLink(cleanURL, link)
+				linkCount++
+
+				category, _ := ClassifyLink(cleanURL)
+				if ls.isInternalLink(cleanURL) && category == CategoryHTML && ls.follows(link) {
+					newInternalLinks = append(newInternalLinks, cleanURL)
+				}
+			}
+		}
+	})
+
+	// Extract images
+	doc.Find("img[src]").Each(func(i int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if !exists {
+			return
+		}
+
+		cleanURL := NormalizeURL(src, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	})
+
+	// Extract scripts
+	doc.Find("script[src]").Each(func(i int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if !exists {
+			return
+		}
+
+		cleanURL := NormalizeURL(src, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	})
+
+	// Extract stylesheets from link tags
+	doc.Find("link[rel='stylesheet']").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		cleanURL := NormalizeURL(href, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	})
+
+	// Extract video and audio sources
+	doc.Find("video source[src], audio source[src]").Each(func(i int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if !exists {
+			return
+		}
+
+		cleanURL := NormalizeURL(src, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	})
+
+	// Extract iframe sources
+	doc.Find("iframe[src]").Each(func(i int, s *goquery.Selection) {
+		src, exists := s.Attr("src")
+		if !exists {
+			return
+		}
+
+		cleanURL := NormalizeURL(src, baseURL)
+		if cleanURL != "" {
+			ls.addLink(cleanURL, origin.from(s))
+			linkCount++
+		}
+	})
+
+	// Extract responsive image candidates (<img srcset>, <picture><source srcset>)
+	doc.Find("img[srcset], source[srcset]").Each(func(i int, s *goquery.Selection) {
+		srcset, _ := s.Attr("srcset")
+		for _, candidate := range parseSrcset(srcset) {
+			addResource(s, candidate)
+		}
+	})
+
+	// Extract media elements without <source> children, and video posters
+	doc.Find("video[src], audio[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		addResource(s, src)
+	})
+	doc.Find("video[poster]").Each(func(i int, s *goquery.Selection) {
+		poster, _ := s.Attr("poster")
+		addResource(s, poster)
+	})
+
+	// Extract embedded objects
+	doc.Find("object[data]").Each(func(i int, s *goquery.Selection) {
+		data, _ := s.Attr("data")
+		addResource(s, data)
+	})
+	doc.Find("embed[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		addResource(s, src)
+	})
+
+	// Extract form endpoints. They are not crawled: submitting a form is not browsing.
+	doc.Find("form[action]").Each(func(i int, s *goquery.Selection) {
+		action, _ := s.Attr("action")
+		addResource(s, action)
+	})
+
+	// Extract url() references of <style> blocks and style attributes
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		for _, ref := range cssURLs(s.Text()) {
+			addResource(s, ref)
+		}
+	})
+	doc.Find("[style]").Each(func(i int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		for _, ref := range cssURLs(style) {
+			addResource(s, ref)
+		}
+	})
+
+	// Extract <meta http-equiv="refresh"> targets, followed like <a> links
+	doc.Find("meta[http-equiv]").Each(func(i int, s *goquery.Selection) {
+		equiv, _ := s.Attr("http-equiv")
+		if !strings.EqualFold(strings.TrimSpace(equiv), "refresh") {
+			return
+		}
+		content, _ := s.Attr("content")
+		cleanURL := NormalizeURL(metaRefreshURL(content), baseURL)
+		if cleanURL != "" {
+			link := origin.from(s)
+			ls.addLink(cleanURL, link)
+			linkCount++
+
+			category, _ := ClassifyLink(cleanURL)
+			if ls.isInternalLink(cleanURL) && category == CategoryHTML && ls.follows(link) {
+				newInternalLinks = append(newInternalLinks, cleanURL)
+			}
+		}
+	})
+
+	return newInternalLinks, linkCount
+}
@@ -0,0 +1,210 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// treeSite serves / -> /1a.html, /1b.html; /1a.html -> /2a.html -> /3a.html
+func treeSite(t *testing.T) *pathRecorder {
+	t.Helper()
+	children := map[string][]string{
+		"/":        {"/1a.html", "/1b.html"},
+		"/1a.html": {"/2a.html"},
+		"/1b.html": {"/1a.html"},
+		"/2a.html": {"/3a.html"},
+		"/3a.html": {"/"},
+	}
+	return newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		links, ok := children[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		for _, link := range links {
+			fmt.Fprintf(w, `<a href="%s">%s</a>`, link, link)
+		}
+	})
+}
+
+func (r *pathRecorder) pages() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var pages []string
+	for _, path := range r.paths {
+		if path != "/robots.txt" {
+			pages = append(pages, path)
+		}
+	}
+	slices.Sort(pages)
+	return pages
+}
+
+func TestCrawlLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  int
+		maxPages  int
+		wantPages []string
+		wantDepth int
+	}{
+		{"home page only", 0, 0, []string{"/"}, 0},
+		{"depth 1", 1, 0, []string{"/", "/1a.html", "/1b.html"}, 1},
+		{"depth 2", 2, 0, []string{"/", "/1a.html", "/1b.html", "/2a.html"}, 2},
+		{"whole site", 5, 0, []string{"/", "/1a.html", "/1b.html", "/2a.html", "/3a.html"}, 3},
+		{"page limit", 5, 2, []string{"/", "/1a.html"}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site := treeSite(t)
+			ls, err := New(Config{
+				BaseURL:  site.URL + "/",
+				MaxDepth: test.maxDepth,
+				MaxPages: test.maxPages,
+				Workers:  1,
+				Reporter: NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ls.Scrape(context.Background()); err != nil {
+				t.Fatalf("Scrape = %v", err)
+			}
+			results := ls.Results()
+
+			if got := site.pages(); !slices.Equal(got, test.wantPages) {
+				t.Errorf("requested pages = %q, want %q", got, test.wantPages)
+			}
+			if got := results.Statistics.PagesVisited; got != len(test.wantPages) {
+				t.Errorf("pages visited = %d, want %d", got, len(test.wantPages))
+			}
+			if got := results.Statistics.MaxDepthReached; got != test.wantDepth {
+				t.Errorf("depth reached = %d, want %d", got, test.wantDepth)
+			}
+			if len(results.Errors) != 0 {
+				t.Errorf("errors = %q", results.Errors)
+			}
+		})
+	}
+}
+
+// TestCrawlWorkers crawls a wide site with several workers: every page is
+// requested once and Scrape returns once the frontier is drained
+func TestCrawlWorkers(t *testing.T) {
+	const width = 50
+	site := newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			for i := range width {
+				fmt.Fprintf(w, `<a href="/page%d.html">Page</a>`, i)
+			}
+			return
+		}
+		// Every page links to all the others, so that workers race for them
+		for i := range width {
+			fmt.Fprintf(w, `<a href="/page%d.html">Page</a><a href="/">Home</a>`, i)
+		}
+	})
+	ls, err := New(Config{
+		BaseURL:  site.URL + "/",
+		MaxDepth: 3,
+		Workers:  8,
+		Reporter: NewConsoleReporter(io.Discard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- ls.Scrape(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Scrape = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Scrape did not return once the site was crawled")
+	}
+
+	pages := site.pages()
+	if len(pages) != width+1 || len(slices.Compact(pages)) != width+1 {
+		t.Errorf("%d pages requested, %d distinct, want %d once each", len(pages), len(slices.Compact(pages)), width+1)
+	}
+	if visited := ls.Results().Statistics.PagesVisited; visited != width+1 {
+		t.Errorf("pages visited = %d, want %d", visited, width+1)
+	}
+	if queued := ls.frontier.size(); queued != 0 {
+		t.Errorf("%d tasks left in the frontier", queued)
+	}
+}
+
+// TestCrawlCancel cancels the crawl while a page is loading: the page is not
+// an error and stays in the saved state, to be scraped by a resumed crawl
+func TestCrawlCancel(t *testing.T) {
+	loading := make(chan struct{})
+	site := newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			io.WriteString(w, `<a href="/slow.html">Slow</a><a href="/next.html">Next</a>`)
+		case "/slow.html":
+			close(loading)
+			<-r.Context().Done()
+		default:
+			io.WriteString(w, `<p>page</p>`)
+		}
+	})
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	ls, err := New(Config{
+		BaseURL:   site.URL + "/",
+		MaxDepth:  1,
+		Workers:   1,
+		StateFile: stateFile,
+		Reporter:  NewConsoleReporter(io.Discard),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ls.Scrape(ctx) }()
+	select {
+	case <-loading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow page never requested")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Scrape = %v, want context.Canceled", err)
+	}
+
+	results := ls.Results()
+	if len(results.Errors) != 0 {
+		t.Errorf("errors = %q, want none for the cancelled page", results.Errors)
+	}
+	if results.Statistics.PagesVisited != 1 {
+		t.Errorf("pages visited = %d, want the home page only", results.Statistics.PagesVisited)
+	}
+	state, err := LoadState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frontier []string
+	for _, task := range state.Frontier {
+		frontier = append(frontier, strings.TrimPrefix(task.URL, site.URL))
+	}
+	slices.Sort(frontier)
+	if want := []string{"/next.html", "/slow.html"}; !slices.Equal(frontier, want) {
+		t.Errorf("saved frontier = %q, want %q", frontier, want)
+	}
+}
@@ -0,0 +1,340 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+)
+
+// BasicAuth holds HTTP basic authentication credentials
+type BasicAuth struct {
+	Username string
+	Password string
+	Hosts    []string // hosts the credentials are sent to (default: the host of BaseURL)
+}
+
+// hostScope is the set of hosts credentials are sent to. A host given
+// without a port matches any port.
+type hostScope map[string]bool
+
+// newHostScope returns the scope of the given hosts, or of the host of baseURL when there are none
+func newHostScope(hosts []string, baseURL *url.URL) hostScope {
+	scope := make(hostScope)
+	for _, host := range hosts {
+		scope[strings.ToLower(host)] = true
+	}
+	if len(scope) == 0 {
+		scope[strings.ToLower(baseURL.Host)] = true
+	}
+	return scope
+}
+
+func (s hostScope) contains(u *url.URL) bool {
+	return s[strings.ToLower(u.Host)] || s[strings.ToLower(u.Hostname())]
+}
+
+// setHeaders adds headers to req when its host is in scope
+func (s hostScope) setHeaders(req *http.Request, headers map[string]string) {
+	if !s.contains(req.URL) {
+		return
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// stripHeaders returns a CheckRedirect function removing headers from the
+// redirects leaving the scope, before calling next (the default policy when nil)
+func (s hostScope) stripHeaders(headers map[string]string, next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !s.contains(req.URL) {
+			for name := range headers {
+				req.Header.Del(name)
+			}
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		// Same limit as the default policy of http.Client
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+// authTransport adds basic authentication to the requests made to the given hosts,
+// so that the credentials are not sent to the external links
+type authTransport struct {
+	base  http.RoundTripper
+	auth  BasicAuth
+	hosts hostScope
+}
+
+func newAuthTransport(base http.RoundTripper, auth BasicAuth, baseURL *url.URL) *authTransport {
+	return &authTransport{base: base, auth: auth, hosts: newHostScope(auth.Hosts, baseURL)}
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !t.hosts.contains(req.URL) {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.auth.Username, t.auth.Password)
+	return t.base.RoundTrip(req)
+}
+
+// NewCookieJar returns an empty in-memory cookie jar, the one used by default
+func NewCookieJar() http.CookieJar {
+	// The error is always nil
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// addCookies stores cookies in jar, for the domain and path they carry. A
+// domain starting with a dot includes its subdomains, otherwise the cookie is
+// only sent to that host. Cookies without a domain are ignored: the jar could
+// not tell where to send them.
+func addCookies(jar http.CookieJar, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		host := strings.TrimPrefix(cookie.Domain, ".")
+		if host == "" {
+			continue
+		}
+		if !strings.HasPrefix(cookie.Domain, ".") {
+			// The jar keeps the cookies set without a domain attribute for their host only
+			hostOnly := *cookie
+			hostOnly.Domain = ""
+			cookie = &hostOnly
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		path := cookie.Path
+		if path == "" {
+			path = "/"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: path}, []*http.Cookie{cookie})
+	}
+}
+
+// LoadCookieFile reads cookies exported in the Netscape format, as written by
+// curl, wget and the browser extensions: one cookie per line, with the domain,
+// include subdomains flag, path, secure flag, expiry, name and value separated
+// by tabs. Cookies copied from a logged in browser let the crawl reach the
+// pages behind the login.
+func LoadCookieFile(path string) ([]*http.Cookie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cookie file: %v", err)
+	}
+	defer file.Close()
+
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		// curl marks the HttpOnly cookies with a prefix that looks like a comment
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("invalid cookie on line %d of %s: expected 7 tab-separated fields", lineNumber, path)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie expiry on line %d of %s: %v", lineNumber, path, err)
+		}
+
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+			Path:     fields[2],
+			Domain:   fields[0],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		// The leading dot tells whether the subdomains get the cookie too
+		cookie.Domain = strings.TrimPrefix(cookie.Domain, ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = "." + cookie.Domain
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading cookie file: %v", err)
+	}
+	return cookies, nil
+}
+
+// LoginStep describes a form submitted before the crawl, so that the session
+// cookies it sets are sent with the following requests
+type LoginStep struct {
+	URL    string            // where the form is posted, or the page of the form when Form is set
+	Fields map[string]string // submitted values, e.g. the username and the password
+
+	// Form is the CSS selector of the login form in the page at URL (e.g.
+	// "form#login"). When set, the page is loaded first and the form is
+	// submitted to its action, along with its other fields (hidden CSRF
+	// tokens...), Fields overriding their values.
+	Form string
+}
+
+// Login submits the login form of step. The session cookies are kept in the
+// cookie jar of the client, shared by the scrapers of a Batch. The login fails
+// when the server answers with an error status. The session of a RenderFetcher
+// can't be logged in.
+func (ls *LinkScraper) Login(ctx context.Context, step LoginStep) error {
+	if ls.client.Jar == nil {
+		return fmt.Errorf("login needs a client with a cookie jar")
+	}
+	if _, ok := ls.fetcher.(*RenderFetcher); ok {
+		return fmt.Errorf("the headless browser can't use the session of a login")
+	}
+
+	target, method, values := step.URL, http.MethodPost, url.Values{}
+	if step.Form != "" {
+		var err error
+		target, method, values, err = ls.loginForm(ctx, step)
+		if err != nil {
+			return err
+		}
+	}
+	for name, value := range step.Fields {
+		values.Set(name, value)
+	}
+
+	var body io.Reader
+	if method == http.MethodGet {
+		targetURL, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("invalid login URL: %v", err)
+		}
+		targetURL.RawQuery = values.Encode()
+		target = targetURL.String()
+	} else {
+		body = strings.NewReader(values.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("error creating login request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	ls.headerHosts.setHeaders(req, ls.headers)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if step.Form != "" {
+		req.Header.Set("Referer", step.URL)
+	}
+
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error submitting login form: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login rejected by %s: %v", target, statusError(resp))
+	}
+
+	ls.logf("🔐 Logged in at %s (%d cookies for %s)", target, len(ls.client.Jar.Cookies(ls.baseURL)), ls.baseURL.Host)
+	return nil
+}
+
+// loginForm loads the page of the login form and returns where and how the
+// form is submitted, along with the values of its fields
+func (ls *LinkScraper) loginForm(ctx context.Context, step LoginStep) (string, string, url.Values, error) {
+	req, err := ls.newRequest(ctx, http.MethodGet, step.URL)
+	if err != nil {
+		return "", "", nil, err
+	}
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error loading login page: %v", err)
+	}
+	defer resp.Body.Close()
+	html, err := readHTML(resp)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error loading login page: %v", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error parsing login page: %v", err)
+	}
+
+	form := doc.Find(step.Form).First()
+	if form.Length() == 0 {
+		return "", "", nil, fmt.Errorf("login form %q not found in %s", step.Form, step.URL)
+	}
+
+	// The page may have been redirected, the action is relative to where it ended
+	pageURL := resp.Request.URL.String()
+	target := pageURL
+	if action, _ := form.Attr("action"); strings.TrimSpace(action) != "" {
+		target = NormalizeURL(action, pageURL)
+	}
+	method := http.MethodPost
+	if value, _ := form.Attr("method"); strings.EqualFold(strings.TrimSpace(value), "get") {
+		method = http.MethodGet
+	}
+
+	values := url.Values{}
+	form.Find("input[name], select[name], textarea[name]").Each(func(i int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		switch goquery.NodeName(s) {
+		case "textarea":
+			values.Set(name, s.Text())
+		case "select":
+			option := s.Find("option[selected]").First()
+			if option.Length() == 0 {
+				option = s.Find("option").First()
+			}
+			value, exists := option.Attr("value")
+			if !exists {
+				value = strings.TrimSpace(option.Text())
+			}
+			values.Set(name, value)
+		default:
+			inputType, _ := s.Attr("type")
+			switch strings.ToLower(inputType) {
+			case "submit", "button", "image", "reset", "file":
+				return
+			case "checkbox", "radio":
+				if _, checked := s.Attr("checked"); !checked {
+					return
+				}
+			}
+			value, exists := s.Attr("value")
+			if !exists && strings.EqualFold(inputType, "checkbox") {
+				value = "on"
+			}
+			values.Set(name, value)
+		}
+	})
+	return target, method, values, nil
+}
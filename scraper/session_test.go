@@ -0,0 +1,411 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// headerRecorder is a test server noting the paths requested with the X-Token header
+type headerRecorder struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	withAuth []string
+}
+
+func newHeaderRecorder(t *testing.T, handler http.HandlerFunc) *headerRecorder {
+	t.Helper()
+	recorder := &headerRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "" {
+			recorder.mutex.Lock()
+			recorder.withAuth = append(recorder.withAuth, r.URL.Path)
+			recorder.mutex.Unlock()
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+func (r *headerRecorder) tokenPaths() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.withAuth...)
+}
+
+func (r *headerRecorder) host() string {
+	u, _ := url.Parse(r.URL)
+	return u.Host
+}
+
+func TestHostScope(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com:8443/")
+	tests := []struct {
+		name  string
+		hosts []string
+		link  string
+		want  bool
+	}{
+		{"base host", nil, "https://example.com:8443/page", true},
+		{"base host other port", nil, "https://example.com/page", false},
+		{"base host case", nil, "https://EXAMPLE.com:8443/page", true},
+		{"external host", nil, "https://cdn.example.net/file.pdf", false},
+		{"subdomain", nil, "https://www.example.com:8443/", false},
+		{"listed host", []string{"api.example.com"}, "https://api.example.com/v1", true},
+		{"listed host any port", []string{"api.example.com"}, "https://api.example.com:9000/v1", true},
+		{"listed hosts replace the base host", []string{"api.example.com"}, "https://example.com:8443/", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, _ := url.Parse(test.link)
+			if got := newHostScope(test.hosts, baseURL).contains(u); got != test.want {
+				t.Errorf("contains(%q) = %v, want %v", test.link, got, test.want)
+			}
+		})
+	}
+}
+
+// TestHeadersStayOnTheirHosts crawls a site linking to an external one and
+// checks that the configured headers only reach the hosts they are meant for,
+// whatever the request: pages, redirects, robots.txt, link checks, Content-Type
+// checks and downloads.
+func TestHeadersStayOnTheirHosts(t *testing.T) {
+	external := newHeaderRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/download":
+			w.Header().Set("Content-Type", "application/zip")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+		}
+		io.WriteString(w, "external")
+	})
+	site := newHeaderRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<a href="/about.html">About</a>
+				<a href="/away">Away</a>
+				<a href="%[1]s/page.html">External</a>
+				<a href="%[1]s/report.pdf">Report</a>
+				<a href="%[1]s/download">Download</a>`, external.URL)
+		case "/about.html":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<p>About</p>")
+		case "/away":
+			http.Redirect(w, r, external.URL+"/landing.html", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		name         string
+		hosts        []string
+		wantSite     bool
+		wantExternal bool
+	}{
+		{"default scope", nil, true, false},
+		{"listed host", []string{external.host()}, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site.withAuth, external.withAuth = nil, nil
+			ls, err := New(Config{
+				BaseURL:     site.URL + "/",
+				MaxDepth:    1,
+				OutputDir:   t.TempDir(),
+				Headers:     map[string]string{"X-Token": "secret"},
+				HeaderHosts: test.hosts,
+				Reporter:    NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			ls.Scrape(ctx)
+			ls.ClassifyByContentType(ctx)
+			ls.CheckLinks(ctx)
+			if err := ls.DownloadAssets(ctx, DownloadOptions{Categories: []LinkCategory{CategoryDocument, CategoryArchive}}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := len(site.tokenPaths()) > 0; got != test.wantSite {
+				t.Errorf("site received the header: %v (%v), want %v", got, site.tokenPaths(), test.wantSite)
+			}
+			if got := len(external.tokenPaths()) > 0; got != test.wantExternal {
+				t.Errorf("external host received the header: %v (%v), want %v", got, external.tokenPaths(), test.wantExternal)
+			}
+		})
+	}
+}
+
+// The browser of a RenderFetcher has its own cookies: the credentials of
+// the crawl must be refused rather than silently left out
+func TestRenderRefusesCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		login  bool
+	}{
+		{"cookies", Config{Cookies: []*http.Cookie{{Name: "session", Value: "1", Domain: "example.com"}}}, false},
+		{"basic auth", Config{BasicAuth: &BasicAuth{Username: "alice", Password: "secret"}}, false},
+		{"login", Config{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.BaseURL = "https://example.com/"
+			config.Fetcher = &RenderFetcher{}
+			config.Reporter = NewConsoleReporter(io.Discard)
+			ls, err := New(config)
+			if test.login && err == nil {
+				err = ls.Login(context.Background(), LoginStep{URL: "https://example.com/login"})
+			}
+			if err == nil {
+				t.Errorf("%s accepted with a RenderFetcher", test.name)
+			}
+		})
+	}
+}
+
+func TestLoadCookieFile(t *testing.T) {
+	content := "# Netscape HTTP Cookie File\n" +
+		"\n" +
+		".example.com\tTRUE\t/\tTRUE\t1900000000\tsession\tabc\n" +
+		"#HttpOnly_intranet.example.com\tFALSE\t/app\tFALSE\t0\ttoken\tx\ty\n"
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := LoadCookieFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []http.Cookie{
+		{Name: "session", Value: "abc", Path: "/", Domain: ".example.com", Secure: true, Expires: time.Unix(1900000000, 0)},
+		{Name: "token", Value: "x\ty", Path: "/app", Domain: "intranet.example.com", HttpOnly: true},
+	}
+	if len(cookies) != len(want) {
+		t.Fatalf("%d cookies, want %d", len(cookies), len(want))
+	}
+	for i, cookie := range cookies {
+		if got := *cookie; got.String() != want[i].String() || !got.Expires.Equal(want[i].Expires) {
+			t.Errorf("cookie %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestLoadCookieFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing fields", "example.com\tFALSE\t/\tFALSE\t0\tsession\n"},
+		{"spaces instead of tabs", "example.com FALSE / FALSE 0 session abc\n"},
+		{"invalid expiry", "example.com\tFALSE\t/\tFALSE\tnever\tsession\tabc\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.txt")
+			if err := os.WriteFile(path, []byte("# comment\n"+test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadCookieFile(path)
+			if err == nil || !strings.Contains(err.Error(), "line 2") {
+				t.Errorf("error = %v, want one about line 2", err)
+			}
+		})
+	}
+}
+
+func TestAddCookies(t *testing.T) {
+	jar := NewCookieJar()
+	addCookies(jar, []*http.Cookie{
+		{Name: "wide", Value: "1", Domain: ".example.com"},
+		{Name: "host", Value: "1", Domain: "example.com"},
+		{Name: "secure", Value: "1", Domain: ".example.com", Secure: true},
+		{Name: "app", Value: "1", Domain: "example.com", Path: "/app"},
+		{Name: "nowhere", Value: "1"},
+	})
+
+	tests := []struct {
+		link string
+		want []string
+	}{
+		{"https://example.com/", []string{"host", "secure", "wide"}},
+		{"http://example.com/", []string{"host", "wide"}},
+		{"http://www.example.com/", []string{"wide"}},
+		{"http://example.com/app/page", []string{"app", "host", "wide"}},
+		{"http://example.net/", nil},
+	}
+	for _, test := range tests {
+		u, _ := url.Parse(test.link)
+		var names []string
+		for _, cookie := range jar.Cookies(u) {
+			names = append(names, cookie.Name)
+		}
+		slices.Sort(names)
+		if !slices.Equal(names, test.want) {
+			t.Errorf("cookies for %s = %q, want %q", test.link, names, test.want)
+		}
+	}
+}
+
+// loginSite only serves its pages with the session cookie, set by a login
+// form protected by a CSRF token
+func loginSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<form id="search" action="/search"><input name="q"></form>
+				<form id="login" action="/session" method="post">
+					<input type="hidden" name="csrf" value="token">
+					<input name="username" value="">
+					<input type="password" name="password">
+					<input type="checkbox" name="remember" value="yes" checked>
+					<input type="submit" name="go" value="Log in">
+				</form>`)
+		case "/session":
+			r.ParseForm()
+			if r.Method != http.MethodPost || r.PostForm.Get("csrf") != "token" ||
+				r.PostForm.Get("username") != "alice" || r.PostForm.Get("password") != "secret" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"})
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+		default:
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "ok" {
+				http.Error(w, "log in first", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `<a href="/private.html">Private</a>`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLogin(t *testing.T) {
+	server := loginSite(t)
+	credentials := map[string]string{"username": "alice", "password": "secret"}
+	tests := []struct {
+		name    string
+		step    LoginStep
+		wantErr bool
+	}{
+		{"form", LoginStep{URL: server.URL + "/login", Form: "form#login", Fields: credentials}, false},
+		{"direct post", LoginStep{URL: server.URL + "/session", Fields: map[string]string{"csrf": "token", "username": "alice", "password": "secret"}}, false},
+		{"wrong password", LoginStep{URL: server.URL + "/login", Form: "form#login", Fields: map[string]string{"username": "alice", "password": "guess"}}, true},
+		{"missing form", LoginStep{URL: server.URL + "/login", Form: "form#signin", Fields: credentials}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := NewBatch(Config{MaxDepth: 1, Reporter: NewConsoleReporter(io.Discard)}, []string{server.URL + "/"}, BatchOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = b.Login(context.Background(), test.step)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Login = %v, want error: %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			b.Scrape(context.Background())
+			results := b.Results()
+			if results.Statistics.PagesVisited != 2 || len(results.Errors) != 0 {
+				t.Errorf("%d pages visited, errors %q: want the 2 pages behind the login", results.Statistics.PagesVisited, results.Errors)
+			}
+		})
+	}
+}
+
+// authRecorder is a test server noting whether the requests carried credentials
+type authRecorder struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	withAuth bool
+}
+
+func newAuthRecorder(t *testing.T, handler http.HandlerFunc) *authRecorder {
+	t.Helper()
+	recorder := &authRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); ok && username == "alice" && password == "secret" {
+			recorder.mutex.Lock()
+			recorder.withAuth = true
+			recorder.mutex.Unlock()
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+func (r *authRecorder) authenticated() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.withAuth
+}
+
+func TestBasicAuthHosts(t *testing.T) {
+	tests := []struct {
+		name         string
+		hosts        func(site, external *authRecorder) []string
+		wantSite     bool
+		wantExternal bool
+	}{
+		{"default scope", func(site, external *authRecorder) []string { return nil }, true, false},
+		{"listed host", func(site, external *authRecorder) []string { return []string{external.Listener.Addr().String()} }, false, true},
+		{"host without port", func(site, external *authRecorder) []string { return []string{"127.0.0.1"} }, true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			external := newAuthRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				io.WriteString(w, "external")
+			})
+			site := newAuthRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprintf(w, `<a href="%s/page.html">External</a>`, external.URL)
+			})
+			ls, err := New(Config{
+				BaseURL:   site.URL + "/",
+				MaxDepth:  1,
+				BasicAuth: &BasicAuth{Username: "alice", Password: "secret", Hosts: test.hosts(site, external)},
+				Reporter:  NewConsoleReporter(io.Discard),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ls.Scrape(context.Background())
+			ls.CheckLinks(context.Background())
+
+			if got := site.authenticated(); got != test.wantSite {
+				t.Errorf("credentials sent to the site: %v, want %v", got, test.wantSite)
+			}
+			if got := external.authenticated(); got != test.wantExternal {
+				t.Errorf("credentials sent to the external host: %v, want %v", got, test.wantExternal)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// Nested sitemap indexes are followed up to this depth
+	maxSitemapDepth = 3
+	// Maximum uncompressed size of a sitemap, as defined by sitemaps.org
+	maxSitemapSize = 50 * 1024 * 1024
+)
+
+// sitemapDocument is either a <urlset> or a <sitemapindex>
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// URLSources tells where the collected links were discovered. In combined
+// results, the figures of the seeds are summed: a link found by several
+// seeds is counted once per seed.
+type URLSources struct {
+	Sitemap int `json:"sitemap"`
+	HTML    int `json:"html"`
+}
+
+// seedFromSitemaps reads the sitemaps announced in robots.txt, or /sitemap.xml
+// when there are none, and adds their URLs to the frontier as crawl seeds.
+func (ls *LinkScraper) seedFromSitemaps(ctx context.Context) {
+	var sitemaps []string
+	if !ls.ignoreRobots {
+		if rules := ls.robotsFor(ctx, ls.baseURL); rules != nil {
+			sitemaps = append(sitemaps, rules.sitemaps...)
+		}
+	}
+	if len(sitemaps) == 0 {
+		sitemaps = append(sitemaps, ls.baseURL.Scheme+"://"+ls.baseURL.Host+"/sitemap.xml")
+	}
+
+	seen := make(map[string]bool)
+	for _, sitemapURL := range sitemaps {
+		ls.readSitemap(ctx, sitemapURL, 0, seen)
+	}
+}
+
+func (ls *LinkScraper) readSitemap(ctx context.Context, sitemapURL string, depth int, seen map[string]bool) {
+	if depth > maxSitemapDepth || seen[sitemapURL] || ctx.Err() != nil {
+		return
+	}
+	seen[sitemapURL] = true
+
+	ls.logf("🗺️  Reading sitemap: %s", sitemapURL)
+	doc, err := ls.fetchSitemap(ctx, sitemapURL)
+	if err != nil {
+		ls.addError(sitemapURL, fmt.Sprintf("Error on sitemap %s: %v", sitemapURL, err))
+		return
+	}
+
+	ls.mutex.Lock()
+	ls.sitemaps = append(ls.sitemaps, sitemapURL)
+	ls.mutex.Unlock()
+
+	// A sitemap index only points to other sitemaps
+	for _, nested := range doc.Sitemaps {
+		ls.readSitemap(ctx, strings.TrimSpace(nested.Loc), depth+1, seen)
+	}
+
+	added := 0
+	for _, entry := range doc.URLs {
+		link := NormalizeURL(entry.Loc, sitemapURL)
+		if link == "" {
+			continue
+		}
+		if ls.addLink(link, ClassifiedLink{SourcePage: sitemapURL, Element: "sitemap"}) {
+			added++
+			ls.mutex.Lock()
+			ls.sitemapLinks++
+			ls.mutex.Unlock()
+		}
+
+		category, _ := ClassifyLink(link)
+		if ls.isInternalLink(link) && category == CategoryHTML {
+			ls.enqueue(link, 0)
+		}
+	}
+	if len(doc.URLs) > 0 {
+		ls.logf("📊 %d new URLs found in sitemap", added)
+	}
+}
+
+func (ls *LinkScraper) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, error) {
+	// Sitemaps are paced like the pages, a large sitemap index must not burst the server
+	parsedURL, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sitemap URL: %v", err)
+	}
+	allowed, err := ls.politeWait(ctx, parsedURL)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("disallowed by robots.txt")
+	}
+
+	req, err := ls.newRequest(ctx, "GET", sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+	}
+
+	// Compressed sitemaps (sitemap.xml.gz) are recognized by the gzip magic number
+	buffered := bufio.NewReader(resp.Body)
+	var reader io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("error creating gzip reader: %v", err)
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	var doc sitemapDocument
+	decoder := xml.NewDecoder(io.LimitReader(reader, maxSitemapSize))
+	// Sitemaps must be UTF-8, but some declare another encoding
+	decoder.CharsetReader = charset.NewReaderLabel
+	err = decoder.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing sitemap: %v", err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("not a sitemap: <%s> root element", doc.XMLName.Local)
+	}
+	return &doc, nil
+}
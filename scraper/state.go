@@ -0,0 +1,224 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// State is the snapshot of a crawl saved on disk, so that an
+// interrupted crawl can be resumed where it stopped (see Config.Resume).
+type State struct {
+	BaseURL         string                            `json:"base_url"`
+	MaxDepth        int                               `json:"max_depth"`
+	Frontier        []CrawlTask                       `json:"frontier"`
+	Visited         []string                          `json:"visited"`
+	Links           []string                          `json:"links"`
+	InternalLinks   []string                          `json:"internal_links"`
+	ExternalLinks   []string                          `json:"external_links"`
+	ClassifiedLinks map[LinkCategory][]ClassifiedLink `json:"classified_links"`
+	Errors          []string                          `json:"errors"`
+	DisallowedURLs  []string                          `json:"disallowed_urls"`
+	ScrapedPages    map[string]string                 `json:"scraped_pages"`
+	RedirectedPages []RedirectedPage                  `json:"redirected_pages"`
+	DuplicatePages  []DuplicatePage                   `json:"duplicate_pages"`
+	CollapsedLinks  []CollapsedLink                   `json:"collapsed_links"`
+	RedirectLoops   int                               `json:"redirect_loops"`
+	Retries         int                               `json:"retries"`
+	RetriedURLs     []string                          `json:"retried_urls"`
+	FailedURLs      []FailedURL                       `json:"failed_urls"`
+	Sitemaps        []string                          `json:"sitemaps"`
+	SitemapLinks    int                               `json:"sitemap_links"`
+	FilterStats     FilterStats                       `json:"filter_stats"`
+	FilteredURLs    []string                          `json:"filtered_urls"`
+	PagesVisited    int                               `json:"pages_visited"`
+	CurrentDepth    int                               `json:"current_depth"`
+	SavedAt         string                            `json:"saved_at"`
+}
+
+// LoadState reads a state file written during a crawl
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file: %v", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error decoding state file: %v", err)
+	}
+	return &state, nil
+}
+
+// snapshotState captures the current crawl. Both locks are held together
+// (ls.mutex first, as everywhere else) so that no page is half-recorded: a
+// page is either still in the frontier or all its links and children are
+// already part of the snapshot. Everything is copied, the crawl goes on.
+func (ls *LinkScraper) snapshotState() *State {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	ls.frontier.mutex.Lock()
+	defer ls.frontier.mutex.Unlock()
+
+	visited := make([]string, 0, len(ls.visitedURL))
+	for link := range ls.visitedURL {
+		visited = append(visited, link)
+	}
+	filtered := make([]string, 0, len(ls.filteredURLs))
+	for link := range ls.filteredURLs {
+		filtered = append(filtered, link)
+	}
+	scrapedPages := make(map[string]string, len(ls.scrapedPages))
+	for link, owner := range ls.scrapedPages {
+		scrapedPages[link] = owner
+	}
+
+	return &State{
+		BaseURL:         ls.baseURL.String(),
+		MaxDepth:        ls.maxDepth,
+		Frontier:        ls.frontier.tasksLocked(),
+		Visited:         visited,
+		Links:           slices.Clone(ls.links),
+		InternalLinks:   slices.Clone(ls.internalLinks),
+		ExternalLinks:   slices.Clone(ls.externalLinks),
+		ClassifiedLinks: cloneClassified(ls.classifiedLinks),
+		Errors:          slices.Clone(ls.errors),
+		DisallowedURLs:  slices.Clone(ls.disallowedURLs),
+		ScrapedPages:    scrapedPages,
+		RedirectedPages: slices.Clone(ls.redirectedPages),
+		DuplicatePages:  slices.Clone(ls.duplicatePages),
+		CollapsedLinks:  slices.Clone(ls.collapsedLinks),
+		RedirectLoops:   ls.redirectLoops,
+		Retries:         ls.retries,
+		RetriedURLs:     slices.Clone(ls.retriedURLs),
+		FailedURLs:      slices.Clone(ls.failedURLs),
+		Sitemaps:        slices.Clone(ls.sitemaps),
+		SitemapLinks:    ls.sitemapLinks,
+		FilterStats:     ls.filterStats,
+		FilteredURLs:    filtered,
+		PagesVisited:    ls.pagesVisited,
+		CurrentDepth:    ls.currentDepth,
+		SavedAt:         time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// StateFile returns the path where the crawl state is saved (empty when it is not)
+func (ls *LinkScraper) StateFile() string {
+	return ls.stateFile
+}
+
+// SaveState writes the current crawl state to the state file
+func (ls *LinkScraper) SaveState() error {
+	if ls.stateFile == "" {
+		return nil
+	}
+
+	ls.stateMutex.Lock()
+	defer ls.stateMutex.Unlock()
+
+	jsonData, err := json.Marshal(ls.snapshotState())
+	if err != nil {
+		return fmt.Errorf("error encoding state: %v", err)
+	}
+
+	// Write to a temporary file first so that an interruption never leaves a truncated state
+	tmpFile := ls.stateFile + ".tmp"
+	err = os.WriteFile(tmpFile, jsonData, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing state file: %v", err)
+	}
+	err = os.Rename(tmpFile, ls.stateFile)
+	if err != nil {
+		return fmt.Errorf("error writing state file: %v", err)
+	}
+	return nil
+}
+
+// restoreState loads a previous crawl of the same website into the scraper.
+// The next call to Crawl continues from the saved frontier instead of the
+// start URL. The passes run after the crawl (link check, Content-Types,
+// downloads) and the proxy statistics are not saved: they start over.
+func (ls *LinkScraper) restoreState(state *State) error {
+	if ls.canonicalKey(state.BaseURL) != ls.canonicalKey(ls.baseURL.String()) {
+		return fmt.Errorf("the state file was saved for %s, not %s", state.BaseURL, ls.baseURL)
+	}
+
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	// The keys are computed again, the rules may have changed since the state was saved
+	for _, link := range state.Visited {
+		ls.visitedURL[ls.canonicalKey(link)] = true
+	}
+	for _, link := range state.Links {
+		ls.linkKeys[ls.canonicalKey(link)] = link
+	}
+	ls.links = append(ls.links, state.Links...)
+	ls.internalLinks = append(ls.internalLinks, state.InternalLinks...)
+	ls.externalLinks = append(ls.externalLinks, state.ExternalLinks...)
+	for category, links := range state.ClassifiedLinks {
+		ls.classifiedLinks[category] = append(ls.classifiedLinks[category], links...)
+	}
+	ls.errors = append(ls.errors, state.Errors...)
+	ls.disallowedURLs = append(ls.disallowedURLs, state.DisallowedURLs...)
+	for link, owner := range state.ScrapedPages {
+		ls.scrapedPages[ls.canonicalKey(link)] = owner
+	}
+	ls.redirectedPages = append(ls.redirectedPages, state.RedirectedPages...)
+	ls.duplicatePages = append(ls.duplicatePages, state.DuplicatePages...)
+	for _, collapsed := range state.CollapsedLinks {
+		ls.collapsedSet[collapsed.URL] = true
+	}
+	ls.collapsedLinks = append(ls.collapsedLinks, state.CollapsedLinks...)
+	ls.redirectLoops = state.RedirectLoops
+	ls.retries = state.Retries
+	for _, link := range state.RetriedURLs {
+		ls.retriedSet[link] = true
+	}
+	ls.retriedURLs = append(ls.retriedURLs, state.RetriedURLs...)
+	ls.failedURLs = append(ls.failedURLs, state.FailedURLs...)
+	ls.sitemaps = append(ls.sitemaps, state.Sitemaps...)
+	ls.sitemapLinks = state.SitemapLinks
+	// The filtered URLs are kept so that they are not counted twice
+	ls.filterStats = state.FilterStats
+	for _, link := range state.FilteredURLs {
+		ls.filteredURLs[link] = true
+	}
+	ls.pagesVisited = state.PagesVisited
+	ls.pagesCrawled = state.PagesVisited
+	ls.resumedPages = state.PagesVisited
+	ls.currentDepth = state.CurrentDepth
+	for _, task := range state.Frontier {
+		ls.frontier.push(task)
+	}
+	ls.resumed = true
+	return nil
+}
+
+// checkpoint saves the state at a regular interval until stop is closed
+func (ls *LinkScraper) checkpoint(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ls.SaveState(); err != nil {
+				ls.addError("", fmt.Sprintf("Error saving state: %v", err))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// defaultStateFile returns where the state is saved when no file was given
+func defaultStateFile(outputDir string) string {
+	if outputDir == "" {
+		return ""
+	}
+	return filepath.Join(outputDir, "state.json")
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+// stateSite serves a home page linking to three pages, which all link to a
+// fourth one; the home page and the last of the three link to an excluded page
+func stateSite(t *testing.T) *pathRecorder {
+	t.Helper()
+	return newPathRecorder(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			io.WriteString(w, `<a href="/a.html">A</a><a href="/b.html">B</a><a href="/c.html">C</a><a href="/skip/x.html">Skip</a>`)
+		case "/a.html", "/b.html":
+			io.WriteString(w, `<a href="/d.html">D</a>`)
+		case "/c.html":
+			io.WriteString(w, `<a href="/d.html">D</a><a href="/skip/x.html">Skip</a>`)
+		case "/d.html":
+			io.WriteString(w, `<p>D</p>`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func stateConfig(baseURL, stateFile string) Config {
+	return Config{
+		BaseURL:         baseURL,
+		MaxDepth:        3,
+		Workers:         1,
+		ExcludePatterns: []string{"/skip/"},
+		StateFile:       stateFile,
+		Reporter:        NewConsoleReporter(io.Discard),
+	}
+}
+
+func TestResumeContinuesTheCrawl(t *testing.T) {
+	site := stateSite(t)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	config := stateConfig(site.URL+"/", stateFile)
+	config.MaxPages = 2
+	first, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Scrape(context.Background())
+	interrupted := first.Results()
+
+	state, err := LoadState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Frontier) == 0 {
+		t.Fatal("no page left in the saved frontier")
+	}
+	config = stateConfig(site.URL+"/", stateFile)
+	config.Resume = state
+	resumed, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed.Scrape(context.Background())
+	results := resumed.Results()
+
+	for _, path := range []string{"/", "/a.html", "/b.html", "/c.html", "/d.html"} {
+		count := 0
+		site.mutex.Lock()
+		for _, requested := range site.paths {
+			if requested == path {
+				count++
+			}
+		}
+		site.mutex.Unlock()
+		if count != 1 {
+			t.Errorf("%s requested %d times over both runs, want 1", path, count)
+		}
+	}
+	if site.requested("/skip/x.html") {
+		t.Error("excluded page requested")
+	}
+	if results.Statistics.PagesVisited != 5 {
+		t.Errorf("pages visited = %d, want 5", results.Statistics.PagesVisited)
+	}
+	if results.TotalLinks != 5 {
+		t.Errorf("links = %q, want 5", results.AllLinks)
+	}
+	// The excluded page found again by the resumed crawl is counted once
+	if got, want := results.Statistics.Filters.Excluded, interrupted.Statistics.Filters.Excluded; got != want || got != 1 {
+		t.Errorf("excluded links = %d after resuming, want %d as before", got, want)
+	}
+}
+
+func TestResumeChecksTheWebsite(t *testing.T) {
+	state := &State{BaseURL: "https://example.com/docs/", MaxDepth: 1}
+	tests := []struct {
+		baseURL string
+		wantErr bool
+	}{
+		{"https://example.com/docs/", false},
+		{"https://example.com/blog/", true},
+		{"https://other.example/docs/", true},
+	}
+	for _, test := range tests {
+		config := stateConfig(test.baseURL, "")
+		config.Resume = state
+		_, err := New(config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("resuming %s on %s: error %v, want error: %v", state.BaseURL, test.baseURL, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+)
+
+func (ls *LinkScraper) isInternalLink(link string) bool {
+	parsedLink, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	// If no host, it's a relative link, thus internal
+	if parsedLink.Host == "" {
+		return true
+	}
+
+	// Compare domains (with and without www)
+	baseHost := strings.ToLower(ls.baseURL.Host)
+	linkHost := strings.ToLower(parsedLink.Host)
+
+	// Remove www. for comparison
+	baseHost = strings.TrimPrefix(baseHost, "www.")
+	linkHost = strings.TrimPrefix(linkHost, "www.")
+
+	return baseHost == linkHost
+}
+
+// NormalizeURL resolves href against baseURL and cleans it (fragment and tracking parameters removed).
+// It returns an empty string for links that can't be scraped (anchors, javascript:, mailto:, ...).
+func NormalizeURL(href, baseURL string) string {
+	// Clean the href
+	href = strings.TrimSpace(href)
+
+	// Ignore empty links, anchors, javascript, and mailto
+	if href == "" || strings.HasPrefix(href, "#") ||
+		strings.HasPrefix(href, "javascript:") ||
+		strings.HasPrefix(href, "mailto:") ||
+		strings.HasPrefix(href, "tel:") ||
+		strings.HasPrefix(href, "ftp:") ||
+		strings.HasPrefix(href, "file:") ||
+		strings.HasPrefix(href, "data:") {
+		return ""
+	}
+
+	// Parse the base URL
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+
+	// Parse the href link
+	link, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	// Resolve the relative URL against the base
+	resolved := base.ResolveReference(link)
+
+	// Clean the URL (remove fragments and unnecessary parameters)
+	resolved.Fragment = ""
+
+	// Remove common tracking parameters
+	query := resolved.Query()
+	trackingParams := []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "fbclid", "gclid"}
+	for _, param := range trackingParams {
+		query.Del(param)
+	}
+	resolved.RawQuery = query.Encode()
+
+	return resolved.String()
+}